@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/rhnauf/recipe-api/external/db"
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+/*
+runExport writes every recipe outside the trash as csv, drafts included, in the same
+format as GET /recipe/export.csv, e.g. go run ./cmd/app export -o recipes.csv
+*/
+func (a *App) runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	output := flags.String("o", "", "file to write, standard output when empty")
+	_ = flags.Parse(args)
+
+	if flags.NArg() > 0 {
+		log.Fatal("usage: export [-o file]")
+	}
+
+	pool, dbDispose := db.NewDatabase(a.config.DB)
+	defer dbDispose()
+
+	ctx, stop := commandContext()
+	defer stop()
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			dbDispose()
+			log.Fatal("error creating export file =>", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	writer := csv.NewWriter(out)
+	_ = writer.Write(entity.RecipeCSVHeader)
+
+	rows := 0
+	recipeRepository := repository.NewRecipeRepository(pool, a.config.DB.Timeouts)
+	err := recipeRepository.ExportRecipes(ctx, entity.Caller{Role: entity.RoleAdmin}, func(recipe *entity.Recipe) error {
+		rows++
+		return writer.Write(recipe.CSVRecord())
+	})
+	if err == nil {
+		writer.Flush()
+		err = writer.Error()
+	}
+	if err != nil {
+		dbDispose()
+		log.Fatal("error exporting recipes =>", err)
+	}
+
+	log.Println("EXPORTED RECIPES =>", rows)
+}
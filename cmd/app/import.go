@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rhnauf/recipe-api/external/db"
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/importer"
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+/*
+runImport imports html pages or json-ld documents given on the command line,
+csv files are recognised by their extension and need the default column names,
+e.g. go run ./cmd/app import -owner 7 -dry-run ./pages/*.html ./catalog.csv
+*/
+func (a *App) runImport(args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	owner := flags.Int64("owner", 0, "user id owning the imported recipes")
+	dryRun := flags.Bool("dry-run", false, "only validate the files")
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		log.Fatal("usage: import [-owner id] file...")
+	}
+
+	var ownerId *int64
+	if *owner != 0 {
+		ownerId = owner
+	}
+
+	pool, dbDispose := db.NewDatabase(a.config.DB)
+	defer dbDispose()
+
+	recipeImporter := importer.NewImporter(repository.NewRecipeRepository(pool, a.config.DB.Timeouts))
+
+	ctx, stop := commandContext()
+	defer stop()
+
+	failed := false
+	for _, path := range flags.Args() {
+		if err := importFile(ctx, recipeImporter, path, ownerId, *dryRun); err != nil {
+			log.Println("ERROR IMPORTING", path, "=>", err)
+			failed = true
+		}
+	}
+
+	if failed {
+		dbDispose()
+		os.Exit(1)
+	}
+}
+
+func importFile(ctx context.Context, recipeImporter *importer.Importer, path string, ownerId *int64, dryRun bool) error {
+	mediaType := entity.JSONLDMediaType
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		mediaType = "text/html"
+	case ".csv":
+		mediaType = "text/csv"
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	source, err := importer.Parse(mediaType, f, nil)
+	if err != nil {
+		return err
+	}
+
+	report, err := recipeImporter.Import(ctx, source, ownerId, dryRun)
+	for _, item := range report.Items {
+		if item.Result != entity.ImportResultImported {
+			log.Printf("%s #%d %q => %s %s", path, item.Index, item.Title, item.Result, item.Error)
+		}
+	}
+	log.Printf("IMPORTED %s => imported %d, valid %d, duplicates %d, invalid %d, failed %d",
+		path, report.Imported, report.Valid, report.Duplicates, report.Invalid, report.Failed)
+
+	return err
+}
@@ -2,54 +2,90 @@ package main
 
 import (
 	"context"
-	"github.com/joho/godotenv"
-	"github.com/rhnauf/recipe-api/external/db"
-	"github.com/rhnauf/recipe-api/internal/api"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
-	"time"
+	"syscall"
+
+	"github.com/rhnauf/recipe-api/internal/config"
+	"github.com/rhnauf/recipe-api/internal/logging"
 )
 
 type App struct {
-	port string
+	config *config.Config
 }
 
-func (a *App) initConfiguration() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatal("error reading env files =>", err)
+/*
+initConfiguration loads the settings from the config file, .env, the environment and the flags given
+before the command, every missing or invalid setting is reported at once, the rest of args is returned.
+The default logger is set up from the settings, so the log calls of the commands come out structured as well
+*/
+func (a *App) initConfiguration(args []string) []string {
+	cfg, rest, err := config.Load(args, os.LookupEnv)
+	var cfgErr *config.Error
+	switch {
+	case errors.As(err, &cfgErr):
+		log.Fatal(err)
+	case errors.Is(err, flag.ErrHelp):
+		usage()
+		os.Exit(0)
+	case err != nil:
+		usage()
+		os.Exit(2)
 	}
-	a.port = os.Getenv("APP_PORT")
-}
-
-func (a *App) runWebServer() {
-	pool, dbDispose := db.NewDatabase()
-	defer dbDispose()
-
-	handler := api.NewAPI(pool)
-	srv := handler.Server(a.port)
 
-	go func() { _ = srv.ListenAndServe() }()
-
-	log.Println("STARTED API ON PORT =>", a.port)
-
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	<-c
+	a.config = cfg
+	slog.SetDefault(logging.New(os.Stderr, cfg.Logging.Format, cfg.Logging.Level))
+	return rest
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+// commandContext is cancelled on interrupt or termination so a command stops its queries instead of leaving them running
+func commandContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
 
-	defer cancel()
+type command struct {
+	name  string
+	usage string
+	run   func(a *App, args []string)
+}
 
-	srv.Shutdown(ctx)
+// every command shares the configuration and the repositories of the server
+var commands = []command{
+	{"serve", "serve", (*App).runServe},
+	{"migrate", "migrate up|down|status|to version", (*App).runMigrate},
+	{"seed", "seed", (*App).runSeed},
+	{"import", "import [-owner id] [-dry-run] file...", (*App).runImport},
+	{"export", "export [-o file]", (*App).runExport},
+	{"user", "user create -email address [-name name] [-role editor|admin]", (*App).runUser},
+	{"recipe", "recipe publish id...", (*App).runRecipe},
+}
 
-	log.Println("SHUT DOWN GRACEFULLY")
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: app [flags] <command> [arguments], serve is run without a command, app -h lists the flags")
+	for _, cmd := range commands {
+		fmt.Fprintln(os.Stderr, "  "+cmd.usage)
+	}
 }
 
 func main() {
 	a := App{}
+	args := a.initConfiguration(os.Args[1:])
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
 
-	a.initConfiguration()
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			cmd.run(&a, args[1:])
+			return
+		}
+	}
 
-	a.runWebServer()
+	usage()
+	os.Exit(2)
 }
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/rhnauf/recipe-api/external/db"
+)
+
+/*
+runMigrate moves the schema with the migrations embedded in the binary,
+e.g. go run ./cmd/app migrate up, migrate down, migrate status or migrate to 3
+*/
+func (a *App) runMigrate(args []string) {
+	usage := "usage: migrate up|down|status|to version"
+	if len(args) == 0 {
+		log.Fatal(usage)
+	}
+
+	pool, dbDispose := db.NewDatabase(a.config.DB)
+	defer dbDispose()
+
+	migrator, err := db.NewMigrator(pool)
+	if err != nil {
+		log.Fatal("error loading migrations =>", err)
+	}
+
+	ctx, stop := commandContext()
+	defer stop()
+
+	var done []db.Migration
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		done, err = migrator.Up(ctx)
+	case args[0] == "down" && len(args) == 1:
+		done, err = migrator.Down(ctx)
+	case args[0] == "to" && len(args) == 2:
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil {
+			log.Fatal("version must be numeric")
+		}
+		done, err = migrator.To(ctx, version)
+	case args[0] == "status" && len(args) == 1:
+		printMigrationStatus(ctx, migrator)
+		return
+	default:
+		log.Fatal(usage)
+	}
+
+	for _, migration := range done {
+		log.Printf("MIGRATED %d %s", migration.Version, migration.Name)
+	}
+	if err != nil {
+		dbDispose()
+		log.Fatal("error migrating =>", err)
+	}
+	if len(done) == 0 {
+		log.Println("NO MIGRATION TO RUN")
+	}
+}
+
+func printMigrationStatus(ctx context.Context, migrator *db.Migrator) {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		log.Fatal("error getting migration status =>", err)
+	}
+
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = "applied " + status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%04d %-40s %s\n", status.Version, status.Name, applied)
+	}
+}
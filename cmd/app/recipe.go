@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/rhnauf/recipe-api/external/db"
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+/*
+runRecipe runs editorial tasks on recipes, publish goes through the same status workflow as the api,
+e.g. go run ./cmd/app recipe publish 3 4
+*/
+func (a *App) runRecipe(args []string) {
+	usage := "usage: recipe publish id..."
+	if len(args) < 2 || args[0] != "publish" {
+		log.Fatal(usage)
+	}
+
+	ids := make([]int64, len(args)-1)
+	for idx, arg := range args[1:] {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			log.Fatal("id must be numeric")
+		}
+		ids[idx] = id
+	}
+
+	pool, dbDispose := db.NewDatabase(a.config.DB)
+	defer dbDispose()
+
+	ctx, stop := commandContext()
+	defer stop()
+
+	recipeRepository := repository.NewRecipeRepository(pool, a.config.DB.Timeouts)
+
+	failed := false
+	for _, id := range ids {
+		if err := publishRecipe(ctx, recipeRepository, id); err != nil {
+			log.Println("ERROR PUBLISHING RECIPE", id, "=>", err)
+			failed = true
+		}
+	}
+
+	if failed {
+		dbDispose()
+		os.Exit(1)
+	}
+}
+
+func publishRecipe(ctx context.Context, recipeRepository repository.RecipeRepository, id int64) error {
+	recipe, err := recipeRepository.GetRecipeById(ctx, entity.Caller{Role: entity.RoleAdmin}, id)
+	if err != nil {
+		return err
+	}
+
+	if recipe.Status == entity.StatusPublished {
+		log.Println("RECIPE ALREADY PUBLISHED =>", id)
+		return nil
+	}
+	if !entity.CanTransition(recipe.Status, entity.StatusPublished) {
+		return entity.TransitionNotAllowed(recipe.Status, entity.StatusPublished)
+	}
+
+	version, err := recipeRepository.TransitionRecipeStatus(ctx, id, recipe.Status, entity.StatusPublished, nil)
+	if err != nil {
+		return err
+	}
+
+	log.Println("PUBLISHED RECIPE =>", id, "VERSION", version)
+	return nil
+}
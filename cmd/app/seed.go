@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"log"
+
+	"github.com/rhnauf/recipe-api/external/db"
+	"github.com/rhnauf/recipe-api/internal/importer"
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+//go:embed seed.csv
+var seedRecipes []byte
+
+// runSeed stores a few sample recipes for development, running it again skips the ones already there
+func (a *App) runSeed(args []string) {
+	if len(args) > 0 {
+		log.Fatal("usage: seed")
+	}
+
+	pool, dbDispose := db.NewDatabase(a.config.DB)
+	defer dbDispose()
+
+	ctx, stop := commandContext()
+	defer stop()
+
+	source, err := importer.NewCSVSource(bytes.NewReader(seedRecipes), nil)
+	if err != nil {
+		log.Fatal("error reading seed recipes =>", err)
+	}
+
+	recipeImporter := importer.NewImporter(repository.NewRecipeRepository(pool, a.config.DB.Timeouts))
+	report, err := recipeImporter.Import(ctx, source, nil, false)
+	if err != nil {
+		dbDispose()
+		log.Fatal("error seeding recipes =>", err)
+	}
+
+	log.Printf("SEEDED RECIPES => imported %d, already there %d, invalid %d, failed %d", report.Imported, report.Duplicates, report.Invalid, report.Failed)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/rhnauf/recipe-api/external/db"
+	"github.com/rhnauf/recipe-api/internal/api"
+	"github.com/rhnauf/recipe-api/internal/health"
+	"github.com/rhnauf/recipe-api/internal/job"
+	"github.com/rhnauf/recipe-api/internal/metrics"
+	"github.com/rhnauf/recipe-api/internal/ratelimit"
+	"github.com/rhnauf/recipe-api/internal/repository"
+	"github.com/rhnauf/recipe-api/internal/tracing"
+)
+
+func (a *App) runServe(args []string) {
+	if len(args) > 0 {
+		log.Fatal("usage: serve")
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), a.config.Tracing, os.Stdout)
+	if err != nil {
+		log.Fatal("error setting up tracing =>", err)
+	}
+
+	pool, dbDispose := db.NewDatabase(a.config.DB)
+	defer dbDispose()
+
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	m := metrics.New(pool, a.config.DB.Name)
+	recipeRepository := repository.ObserveRecipeRepository(repository.NewRecipeRepository(pool, a.config.DB.Timeouts), m.ObserveOperation, tracing.ObserveOperation)
+
+	purger := job.NewTrashPurger(recipeRepository, a.config.Jobs.TrashRetention, a.config.Jobs.TrashPurgeInterval)
+	go purger.Run(jobCtx)
+
+	publisher := job.NewScheduledPublisher(recipeRepository, a.config.Jobs.PublishInterval)
+	go publisher.Run(jobCtx)
+
+	migrator, err := db.NewMigrator(pool)
+	if err != nil {
+		dbDispose()
+		log.Fatal("error loading migrations =>", err)
+	}
+
+	checks := []health.Check{health.Database(pool), health.Migrations(migrator)}
+	if a.config.Health.BlobStorageURL != "" {
+		checks = append(checks, health.HTTP("blob_storage", a.config.Health.BlobStorageURL, http.DefaultClient))
+	}
+	checker := health.NewChecker(a.config.Health.CheckTimeout, checks...)
+
+	var limiter *ratelimit.Limiter
+	if a.config.RateLimit.Enabled {
+		limiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore(), api.NewRateLimitKey(a.config.RateLimit.APIKeyHashes), a.config.RateLimit.Default, a.config.RateLimit.Routes)
+	}
+
+	handler := api.NewAPI(pool, recipeRepository, checker, m, limiter)
+	srv := handler.Server(strconv.Itoa(a.config.App.Port))
+
+	// the requests still running when the shutdown times out get their context cancelled, aborting their queries
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+	srv.BaseContext = func(net.Listener) context.Context { return requestCtx }
+
+	go func() { _ = srv.ListenAndServe() }()
+
+	slog.Info("started api", "port", a.config.App.Port)
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	<-c
+
+	// /readyz turns 503 first, giving the load balancer time to stop routing new requests here
+	checker.Drain()
+	slog.Info("draining before shutdown", "drain", a.config.App.ShutdownDrain.String())
+	time.Sleep(a.config.App.ShutdownDrain)
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.App.ShutdownTimeout)
+
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("error shutting down", "error", err)
+		cancelRequests()
+	}
+	stopJobs()
+
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("error flushing spans", "error", err)
+	}
+
+	slog.Info("shut down gracefully")
+}
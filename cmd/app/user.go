@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/rhnauf/recipe-api/external/db"
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+/*
+runUser manages the accounts, the printed id is the one the gateway forwards as X-User-Id,
+e.g. go run ./cmd/app user create -email chef@example.com -name chef -role editor
+*/
+func (a *App) runUser(args []string) {
+	usage := "usage: user create -email address [-name name] [-role editor|admin]"
+	if len(args) == 0 || args[0] != "create" {
+		log.Fatal(usage)
+	}
+
+	flags := flag.NewFlagSet("user create", flag.ExitOnError)
+	email := flags.String("email", "", "email address of the user")
+	name := flags.String("name", "", "display name of the user")
+	role := flags.String("role", "", "editor or admin, a regular author has none")
+	_ = flags.Parse(args[1:])
+
+	if flags.NArg() > 0 {
+		log.Fatal(usage)
+	}
+
+	user := entity.User{Email: *email, Name: *name, Role: *role}
+	if err := user.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	pool, dbDispose := db.NewDatabase(a.config.DB)
+	defer dbDispose()
+
+	ctx, stop := commandContext()
+	defer stop()
+
+	id, err := repository.NewUserRepository(pool, a.config.DB.Timeouts).InsertUser(ctx, user)
+	if err != nil {
+		dbDispose()
+		log.Fatal("error creating user =>", err)
+	}
+
+	log.Println("CREATED USER =>", id)
+}
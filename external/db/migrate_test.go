@@ -0,0 +1,254 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+const (
+	lockQry    = "SELECT pg_advisory_lock($1)"
+	unlockQry  = "SELECT pg_advisory_unlock($1)"
+	tableQry   = "CREATE TABLE IF NOT EXISTS schema_migrations ( version bigint PRIMARY KEY, name varchar(100) NOT NULL, applied_at timestamptz DEFAULT now() NOT NULL )"
+	appliedQry = "SELECT version, applied_at FROM schema_migrations ORDER BY version"
+	insertQry  = "INSERT INTO schema_migrations(version, name) VALUES ($1, $2)"
+	deleteQry  = "DELETE FROM schema_migrations WHERE version = $1"
+	versionQry = "SELECT COALESCE(MAX(version), 0) FROM schema_migrations"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "create_a", Up: "CREATE TABLE a (id int)", Down: "DROP TABLE a"},
+	{Version: 2, Name: "create_b", Up: "CREATE TABLE b (id int)", Down: "DROP TABLE b"},
+	{Version: 3, Name: "create_c", Up: "CREATE TABLE c (id int)", Down: "DROP TABLE c"},
+}
+
+func expectLocked(mock sqlmock.Sqlmock, applied ...int64) {
+	mock.ExpectExec(lockQry).WithArgs(migrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(tableQry).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, version := range applied {
+		rows.AddRow(version, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	}
+	mock.ExpectQuery(appliedQry).WillReturnRows(rows)
+}
+
+func expectRun(mock sqlmock.Sqlmock, migration Migration, up bool) {
+	mock.ExpectBegin()
+	if up {
+		mock.ExpectExec(migration.Up).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertQry).WithArgs(migration.Version, migration.Name).WillReturnResult(sqlmock.NewResult(0, 1))
+	} else {
+		mock.ExpectExec(migration.Down).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(deleteQry).WithArgs(migration.Version).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func expectUnlocked(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(unlockQry).WithArgs(migrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func assertVersions(t *testing.T, got []Migration, want ...int64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d migrations, want %d", len(got), len(want))
+	}
+	for idx := range got {
+		if got[idx].Version != want[idx] {
+			t.Errorf("got version %d at %d, want %d", got[idx].Version, idx, want[idx])
+		}
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("should load the embedded migrations in version order", func(t *testing.T) {
+		m, err := NewMigrator(nil)
+		if err != nil {
+			t.Fatalf("error loading migrations, %v", err)
+		}
+
+		if m.Latest() != int64(len(m.migrations)) {
+			t.Errorf("got latest %d, want %d", m.Latest(), len(m.migrations))
+		}
+		for idx, migration := range m.migrations {
+			if migration.Version != int64(idx+1) {
+				t.Errorf("got version %d at %d, want %d", migration.Version, idx, idx+1)
+			}
+		}
+	})
+
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"should reject a migration without down script", fstest.MapFS{
+			"0001_create_a.up.sql": {Data: []byte("CREATE TABLE a (id int)")},
+		}},
+		{"should reject an unexpected file", fstest.MapFS{
+			"create_a.sql": {Data: []byte("CREATE TABLE a (id int)")},
+		}},
+		{"should reject a version with two names", fstest.MapFS{
+			"0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id int)")},
+			"0001_create_b.down.sql": {Data: []byte("DROP TABLE b")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadMigrations(tt.files); err == nil {
+				t.Errorf("got nil, want error")
+			}
+		})
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+
+	newMigrator := func(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+		}
+		t.Cleanup(func() {
+			db.Close()
+		})
+		return &Migrator{db: db, migrations: testMigrations}, mock
+	}
+
+	t.Run("should apply the pending migrations in order", func(t *testing.T) {
+		m, mock := newMigrator(t)
+
+		expectLocked(mock, 1)
+		expectRun(mock, testMigrations[1], true)
+		expectRun(mock, testMigrations[2], true)
+		expectUnlocked(mock)
+
+		done, err := m.Up(ctx)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		assertVersions(t, done, 2, 3)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should revert only the latest migration", func(t *testing.T) {
+		m, mock := newMigrator(t)
+
+		expectLocked(mock, 1, 2)
+		expectRun(mock, testMigrations[1], false)
+		expectUnlocked(mock)
+
+		done, err := m.Down(ctx)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		assertVersions(t, done, 2)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should revert down to the target version", func(t *testing.T) {
+		m, mock := newMigrator(t)
+
+		expectLocked(mock, 1, 2, 3)
+		expectRun(mock, testMigrations[2], false)
+		expectRun(mock, testMigrations[1], false)
+		expectUnlocked(mock)
+
+		done, err := m.To(ctx, 1)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		assertVersions(t, done, 3, 2)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should stop and roll back on a failing migration", func(t *testing.T) {
+		m, mock := newMigrator(t)
+
+		expectLocked(mock)
+		expectRun(mock, testMigrations[0], true)
+		mock.ExpectBegin()
+		mock.ExpectExec(testMigrations[1].Up).WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+		expectUnlocked(mock)
+
+		done, err := m.Up(ctx)
+		if !errors.Is(err, sql.ErrConnDone) {
+			t.Errorf("got %v, want %v", err, sql.ErrConnDone)
+		}
+
+		assertVersions(t, done, 1)
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("should refuse a database migrated by a newer binary", func(t *testing.T) {
+		m, mock := newMigrator(t)
+
+		expectLocked(mock, 1, 2, 3, 4)
+		expectUnlocked(mock)
+
+		if _, err := m.Up(ctx); err == nil {
+			t.Errorf("got nil, want error")
+		}
+	})
+
+	t.Run("should reject a version out of range", func(t *testing.T) {
+		m, _ := newMigrator(t)
+
+		if _, err := m.To(ctx, 4); err == nil {
+			t.Errorf("got nil, want error")
+		}
+	})
+
+	t.Run("should list applied and pending migrations", func(t *testing.T) {
+		m, mock := newMigrator(t)
+
+		expectLocked(mock, 1)
+		expectUnlocked(mock)
+
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		if len(statuses) != 3 || statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil || statuses[2].AppliedAt != nil {
+			t.Errorf("got %v, want migration 1 applied and 2, 3 pending", statuses)
+		}
+	})
+
+	t.Run("should read the latest applied version without locking", func(t *testing.T) {
+		m, mock := newMigrator(t)
+
+		mock.ExpectQuery(versionQry).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+
+		version, err := m.Version(ctx)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		if version != 2 {
+			t.Errorf("got version %d, want %d", version, 2)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+}
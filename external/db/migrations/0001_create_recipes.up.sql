@@ -1,12 +1,13 @@
-create table recipes
-(
-    id          serial
-        primary key,
-    created_at  timestamp default now() not null,
-    title       varchar(100)            not null
-        constraint uq_title
-            unique,
-    description text,
-    instruction text,
-    publish     boolean   default false not null
-);
\ No newline at end of file
+-- the schema of the former ddl.sql, a database created with it is adopted as is and brought up to date by the next migrations
+create table if not exists recipes
+(
+    id          serial
+        primary key,
+    created_at  timestamp default now() not null,
+    title       varchar(100)            not null
+        constraint uq_title
+            unique,
+    description text,
+    instruction text,
+    publish     boolean   default false not null
+);
@@ -0,0 +1,3 @@
+alter table recipes
+    drop column forked_from_id,
+    drop column owner_id;
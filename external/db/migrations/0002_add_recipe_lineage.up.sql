@@ -0,0 +1,8 @@
+alter table recipes
+    add column owner_id       bigint,
+    add column forked_from_id integer
+        constraint fk_forked_from
+            references recipes
+            on delete set null;
+
+create index idx_recipes_forked_from_id on recipes (forked_from_id);
@@ -0,0 +1,21 @@
+create table if not exists recipe_revisions
+(
+    id          serial
+        primary key,
+    recipe_id   integer                 not null
+        constraint fk_recipe
+            references recipes
+            on delete cascade,
+    revision    integer                 not null,
+    title       varchar(100)            not null,
+    description text,
+    instruction text,
+    created_by  bigint,
+    created_at  timestamp default now() not null,
+    constraint uq_recipe_revision
+        unique (recipe_id, revision)
+);
+
+-- the recipes created before get their content as the first revision so their first edit can be undone
+insert into recipe_revisions(recipe_id, revision, title, description, instruction, created_by)
+select id, 1, title, description, instruction, owner_id from recipes;
@@ -0,0 +1,2 @@
+alter table recipes
+    drop column version;
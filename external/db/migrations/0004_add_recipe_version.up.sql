@@ -0,0 +1,2 @@
+alter table recipes
+    add column version integer default 1 not null;
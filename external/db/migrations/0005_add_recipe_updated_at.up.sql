@@ -0,0 +1,5 @@
+-- the recipes created before have not been updated since
+alter table recipes
+    add column updated_at timestamp default now() not null;
+
+update recipes set updated_at = created_at;
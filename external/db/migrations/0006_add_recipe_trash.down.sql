@@ -0,0 +1,9 @@
+-- the trash is emptied first, its titles may be taken again by now
+delete from recipes where deleted_at is not null;
+
+drop index uq_title;
+
+alter table recipes
+    add constraint uq_title
+        unique (title),
+    drop column deleted_at;
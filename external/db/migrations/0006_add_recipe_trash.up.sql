@@ -0,0 +1,10 @@
+alter table recipes
+    add column deleted_at timestamp;
+
+-- trashed recipes do not hold on to their title
+alter table recipes
+    drop constraint uq_title;
+
+create unique index uq_title on recipes (title) where deleted_at is null;
+
+create index idx_recipes_deleted_at on recipes (deleted_at) where deleted_at is not null;
@@ -0,0 +1,9 @@
+drop table if exists recipe_status_transitions;
+
+alter table recipes
+    add column publish boolean default false not null;
+
+update recipes set publish = status = 'published';
+
+alter table recipes
+    drop column status;
@@ -0,0 +1,26 @@
+alter table recipes
+    add column status varchar(20) default 'draft' not null
+        constraint chk_status
+            check (status in ('draft', 'in_review', 'published', 'archived'));
+
+-- the published recipes stay published, the others become drafts
+update recipes set status = case when publish then 'published' else 'draft' end;
+
+alter table recipes
+    drop column publish;
+
+create table if not exists recipe_status_transitions
+(
+    id          serial
+        primary key,
+    recipe_id   integer                 not null
+        constraint fk_recipe
+            references recipes
+            on delete cascade,
+    from_status varchar(20)             not null,
+    to_status   varchar(20)             not null,
+    changed_by  bigint,
+    changed_at  timestamp default now() not null
+);
+
+create index if not exists idx_recipe_status_transitions_recipe_id on recipe_status_transitions (recipe_id);
@@ -0,0 +1,4 @@
+alter table recipes
+    add column publish_at timestamptz;
+
+create index idx_recipes_publish_at on recipes (publish_at) where status = 'in_review';
@@ -0,0 +1,4 @@
+drop table if exists recipe_slug_redirects;
+
+alter table recipes
+    drop column slug;
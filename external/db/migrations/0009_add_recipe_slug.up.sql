@@ -0,0 +1,54 @@
+alter table recipes
+    add column slug varchar(120);
+
+-- slugs stay reserved while a recipe is in the trash so restoring it never collides
+create unique index uq_slug on recipes (slug);
+
+-- the existing recipes get the slug helper.Slugify gives their title, in the order they were created,
+-- a slug already taken gets the first free numeric suffix like helper.NextSlug does
+do
+$$
+    declare
+        recipe    record;
+        base      text;
+        candidate text;
+        n         integer;
+    begin
+        for recipe in select id, title from recipes order by id
+            loop
+                base := trim(both '-' from regexp_replace(
+                    translate(replace(replace(replace(replace(replace(lower(recipe.title), 'æ', 'ae'), 'œ', 'oe'), 'ß', 'ss'), 'þ', 'th'), '&', 'and'),
+                    'àáâãäåāăąçćčĉċďđðèéêëēĕėęěğĝġģĥħìíîïĩīĭįıĵķĺļľŀłñńņňòóôõöøōŏőŕřŗśšşŝșťţțŧùúûüũūŭůűųŵýÿŷźżž',
+                    'aaaaaaaaacccccdddeeeeeeeeegggghhiiiiiiiiijklllllnnnnooooooooorrrsssssttttuuuuuuuuuuwyyyzzz'),
+                    '[^a-z0-9]+', '-', 'g'));
+                base := rtrim(left(base, 99), '-');
+                if base = '' then
+                    base := 'recipe';
+                end if;
+
+                candidate := base;
+                n := 2;
+                while exists(select 1 from recipes where slug = candidate)
+                    loop
+                        candidate := base || '-' || n;
+                        n := n + 1;
+                    end loop;
+
+                update recipes set slug = candidate where id = recipe.id;
+            end loop;
+    end
+$$;
+
+-- the slugs a recipe had before its title changed, served as permanent redirects
+create table if not exists recipe_slug_redirects
+(
+    slug       varchar(120)            not null
+        primary key,
+    recipe_id  integer                 not null
+        constraint fk_recipe
+            references recipes
+            on delete cascade,
+    created_at timestamp default now() not null
+);
+
+create index if not exists idx_recipe_slug_redirects_recipe_id on recipe_slug_redirects (recipe_id);
@@ -0,0 +1,15 @@
+-- the accounts the gateway forwards as X-User-Id and X-User-Role, recipes keep a plain owner_id
+-- as ids forwarded before the table existed have no row
+create table if not exists users
+(
+    id         bigserial
+        primary key,
+    email      varchar(254)            not null,
+    name       varchar(100),
+    role       varchar(20)
+        constraint chk_role
+            check (role in ('editor', 'admin')),
+    created_at timestamp default now() not null
+);
+
+create unique index if not exists uq_users_email on users (lower(email));
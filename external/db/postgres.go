@@ -1,36 +1,73 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
 	_ "github.com/lib/pq"
 	"log"
-	"os"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/config"
+	"github.com/rhnauf/recipe-api/internal/tracing"
 )
 
-func NewDatabase() (*sql.DB, func() error) {
-	port := os.Getenv("DB_PORT")
-	connectionString := fmt.Sprintf(
-		"host=%s port=%s user=%s dbname=%s password=%s sslmode=%s",
-		os.Getenv("DB_HOST"),
-		port,
-		os.Getenv("DB_USERNAME"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("SSL_MODE"),
-	)
-
-	dbConn, err := sql.Open("postgres", connectionString)
+/*
+NewDatabase opens the pool and waits for postgres to answer, the first ping is retried
+with a growing backoff so the app can start before the database in a container setup
+*/
+func NewDatabase(cfg config.DB) (*sql.DB, func() error) {
+	dbConn, err := tracing.Open(cfg.DataSourceName())
 	if err != nil {
 		log.Fatal("ERROR CONNECTING TO DB =>", err)
 	}
 
-	err = dbConn.Ping()
+	dbConn.SetMaxOpenConns(cfg.MaxOpenConns)
+	dbConn.SetMaxIdleConns(cfg.MaxIdleConns)
+	dbConn.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	dbConn.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ConnectTimeout)
+	defer cancel()
+
+	err = waitForDatabase(ctx, dbConn.PingContext, cfg.ConnectBackoff, cfg.ConnectBackoffMax)
 	if err != nil {
 		log.Fatal("ERROR PING TO DB =>", err)
 	}
 
-	log.Println("SUCCESSFULLY CONNECTED TO DB ON PORT =>", port)
+	slog.Info("connected to db", "host", cfg.Host, "port", cfg.Port)
 
 	return dbConn, dbConn.Close
 }
+
+// waitForDatabase pings until it succeeds or ctx is done, the error of the last ping is returned
+func waitForDatabase(ctx context.Context, ping func(ctx context.Context) error, backoff, maxBackoff time.Duration) error {
+	for attempt := 0; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		wait := backoffDelay(attempt, backoff, maxBackoff)
+		slog.Warn("error ping to db, retrying", "error", err, "attempt", attempt+1, "wait", wait.String())
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// backoffDelay doubles from backoff up to maxBackoff, with up to a quarter of jitter so restarted replicas do not ping in step
+func backoffDelay(attempt int, backoff, maxBackoff time.Duration) time.Duration {
+	delay := backoff
+	for i := 0; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay - time.Duration(rand.Int63n(int64(delay)/4+1))
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitForDatabase(t *testing.T) {
+	t.Run("should retry until the ping succeeds", func(t *testing.T) {
+		pings := 0
+		ping := func(ctx context.Context) error {
+			pings++
+			if pings < 3 {
+				return sql.ErrConnDone
+			}
+			return nil
+		}
+
+		if err := waitForDatabase(context.Background(), ping, time.Millisecond, 2*time.Millisecond); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if pings != 3 {
+			t.Errorf("got %d pings, want %d", pings, 3)
+		}
+	})
+
+	t.Run("should give up with the last error when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		ping := func(ctx context.Context) error { return sql.ErrConnDone }
+
+		err := waitForDatabase(ctx, ping, time.Millisecond, 5*time.Millisecond)
+		if !errors.Is(err, sql.ErrConnDone) {
+			t.Errorf("got %v, want %v", err, sql.ErrConnDone)
+		}
+	})
+}
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{0, 75 * time.Millisecond, 100 * time.Millisecond},
+		{1, 150 * time.Millisecond, 200 * time.Millisecond},
+		{3, 600 * time.Millisecond, 800 * time.Millisecond},
+		{10, 750 * time.Millisecond, time.Second},
+	}
+
+	for _, tt := range tests {
+		got := backoffDelay(tt.attempt, 100*time.Millisecond, time.Second)
+		if got < tt.min || got > tt.max {
+			t.Errorf("attempt %d got %v, want between %v and %v", tt.attempt, got, tt.min, tt.max)
+		}
+	}
+}
@@ -5,20 +5,35 @@ import (
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rhnauf/recipe-api/internal/health"
+	"github.com/rhnauf/recipe-api/internal/helper"
+	"github.com/rhnauf/recipe-api/internal/importer"
+	"github.com/rhnauf/recipe-api/internal/logging"
+	"github.com/rhnauf/recipe-api/internal/metrics"
+	"github.com/rhnauf/recipe-api/internal/ratelimit"
 	"github.com/rhnauf/recipe-api/internal/repository"
+	"github.com/rhnauf/recipe-api/internal/tracing"
 	"net/http"
 )
 
 type api struct {
 	recipeRepository repository.RecipeRepository
+	recipeImporter   *importer.Importer
+	dbStats          func() sql.DBStats
+	health           *health.Checker
+	metrics          *metrics.Metrics
+	limiter          *ratelimit.Limiter
 }
 
-func NewAPI(pool *sql.DB) *api {
-
-	recipeRepository := repository.NewRecipeRepository(pool)
-
+// the repository is shared with the background jobs, already instrumented by m, a nil limiter leaves the routes unlimited
+func NewAPI(pool *sql.DB, recipeRepository repository.RecipeRepository, checker *health.Checker, m *metrics.Metrics, limiter *ratelimit.Limiter) *api {
 	return &api{
 		recipeRepository: recipeRepository,
+		recipeImporter:   importer.NewImporter(recipeRepository),
+		dbStats:          pool.Stats,
+		health:           checker,
+		metrics:          m,
+		limiter:          limiter,
 	}
 }
 
@@ -36,15 +51,58 @@ func (a *api) Server(port string) *http.Server {
 func (a *api) Routes() *chi.Mux {
 	r := chi.NewRouter()
 
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	// the recoverer runs inside the request log and the metrics so a panic is logged and counted as the 500 it answers
 	r.Use(middleware.Heartbeat("/ping"))
+	r.Use(tracing.Middleware)
+	r.Use(logging.Middleware)
+	if a.metrics != nil {
+		r.Use(a.metrics.Middleware)
+	}
+	r.Use(middleware.Recoverer)
+	r.Use(identify)
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		helper.HandleProblem(w, r, http.StatusNotFound, "route not found", nil)
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		helper.HandleProblem(w, r, http.StatusMethodNotAllowed, "method not allowed", nil)
+	})
+
+	r.Get("/healthz", a.healthz)
+	r.Get("/readyz", a.readyz)
+	if a.metrics != nil {
+		r.Handle("/metrics", a.metrics.Handler())
+	}
+
+	r.Get("/stats/db", a.getDBStats)
+
+	// the operational routes above are probed often and are not rate limited
+	r.Group(func(r chi.Router) {
+		if a.limiter != nil {
+			r.Use(a.limiter.Middleware)
+		}
 
-	r.Post("/recipe", a.insertRecipe)
-	r.Put("/recipe/{id}", a.updateRecipe)
-	r.Get("/recipe/{id}", a.getRecipeById)
-	r.Delete("/recipe/{id}", a.deleteRecipeById)
-	r.Get("/recipe-list", a.getListRecipe)
+		r.Post("/recipe", a.insertRecipe)
+		r.Post("/recipe/import", a.importRecipes)
+		r.Put("/recipe/{id}", a.updateRecipe)
+		r.Get("/recipe/{id}", a.getRecipeById)
+		r.Get("/recipe/slug/{slug}", a.getRecipeBySlug)
+		r.Get("/recipe/{id}/jsonld", a.getRecipeJSONLD)
+		r.Delete("/recipe/{id}", a.deleteRecipeById)
+		r.Get("/recipe-list", a.getListRecipe)
+		r.Get("/recipe/export.csv", a.exportRecipesCSV)
+		r.Get("/cookbook", a.getCookbook)
+		r.Post("/recipe/{id}/fork", a.forkRecipe)
+		r.Get("/recipe/{id}/lineage", a.getRecipeLineage)
+		r.Get("/recipe/{id}/revisions", a.getRecipeRevisions)
+		r.Get("/recipe/{id}/revisions/diff", a.getRecipeRevisionDiff)
+		r.Get("/recipe/{id}/revisions/{rev}", a.getRecipeRevision)
+		r.Post("/recipe/{id}/revisions/{rev}/restore", a.restoreRecipeRevision)
+		r.Post("/recipe/{id}/restore", a.restoreDeletedRecipe)
+		r.Get("/trash", a.getListDeletedRecipe)
+		r.Post("/recipe/{id}/transitions", a.transitionRecipeStatus)
+		r.Get("/recipe/{id}/transitions", a.getRecipeStatusTransitions)
+	})
 
 	return r
 }
@@ -1,9 +1,19 @@
 package api
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/health"
+	"github.com/rhnauf/recipe-api/internal/helper"
+	"github.com/rhnauf/recipe-api/internal/metrics"
 )
 
 func TestRoutes(t *testing.T) {
@@ -21,4 +31,103 @@ func TestRoutes(t *testing.T) {
 			t.Errorf("got %d, want %d", statusCode, 200)
 		}
 	})
+
+	t.Run("unknown route should return 404 problem", func(t *testing.T) {
+		a := api{}
+
+		req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+		rec := httptest.NewRecorder()
+
+		a.Routes().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("got %d, want %d", rec.Code, http.StatusNotFound)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != helper.ProblemMediaType {
+			t.Errorf("got Content-Type %q, want %q", contentType, helper.ProblemMediaType)
+		}
+	})
+
+	t.Run("healthz should return 200 without checking the dependencies", func(t *testing.T) {
+		a := api{health: health.NewChecker(time.Second, health.Check{Name: "database", Probe: func(ctx context.Context) error { return sql.ErrConnDone }})}
+
+		req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+		rec := httptest.NewRecorder()
+
+		a.Routes().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("got %d, want %d", rec.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("metrics should count the requests by route pattern", func(t *testing.T) {
+		a := api{metrics: metrics.New(nil, ""), health: health.NewChecker(time.Second)}
+		routes := a.Routes()
+
+		routes.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		want := `recipe_api_http_requests_total{method="GET",route="/healthz",status="200"} 1`
+		if body := rec.Body.String(); !strings.Contains(body, want) {
+			t.Errorf("got %s, want %s", body, want)
+		}
+	})
+
+	t.Run("metrics should count a request that panicked as a 500", func(t *testing.T) {
+		a := api{metrics: metrics.New(nil, "")}
+		routes := a.Routes()
+
+		// without a pool the db stats handler panics
+		routes.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stats/db", nil))
+
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		want := `recipe_api_http_requests_total{method="GET",route="/stats/db",status="500"} 1`
+		if body := rec.Body.String(); !strings.Contains(body, want) {
+			t.Errorf("got %s, want %s", body, want)
+		}
+	})
+
+	readyzTests := []struct {
+		name       string
+		probeErr   error
+		drain      bool
+		wantCode   int
+		wantStatus string
+	}{
+		{"readyz should return 200 when the dependencies are up", nil, false, http.StatusOK, entity.HealthReady},
+		{"readyz should return 503 when a dependency is down", sql.ErrConnDone, false, http.StatusServiceUnavailable, entity.HealthNotReady},
+		{"readyz should return 503 while draining", nil, true, http.StatusServiceUnavailable, entity.HealthDraining},
+	}
+
+	for _, tt := range readyzTests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.NewChecker(time.Second, health.Check{Name: "database", Probe: func(ctx context.Context) error { return tt.probeErr }})
+			if tt.drain {
+				checker.Drain()
+			}
+			a := api{health: checker}
+
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			rec := httptest.NewRecorder()
+
+			a.Routes().ServeHTTP(rec, req)
+
+			var res entity.HealthDTO
+			if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+				t.Fatalf("error decoding response body, %v", err.Error())
+			}
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", rec.Code, tt.wantCode)
+			}
+			if res.Status != tt.wantStatus {
+				t.Errorf("got status %s, want %s", res.Status, tt.wantStatus)
+			}
+		})
+	}
 }
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+	"github.com/rhnauf/recipe-api/internal/logging"
+)
+
+type callerCtxKey struct{}
+
+const (
+	HeaderUserId   = "X-User-Id"
+	HeaderUserRole = "X-User-Role"
+)
+
+// identify attaches the caller forwarded by the gateway, anonymous requests pass through untouched
+func identify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userId, err := strconv.ParseInt(r.Header.Get(HeaderUserId), 10, 64)
+		if err != nil || userId <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		logging.With(r.Context(), "user_id", userId)
+
+		ctx := contextWithCaller(r.Context(), entity.Caller{Id: userId, Role: r.Header.Get(HeaderUserRole)})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func contextWithCaller(ctx context.Context, caller entity.Caller) context.Context {
+	return context.WithValue(ctx, callerCtxKey{}, caller)
+}
+
+func callerFromContext(ctx context.Context) (entity.Caller, bool) {
+	caller, ok := ctx.Value(callerCtxKey{}).(entity.Caller)
+	return caller, ok
+}
+
+/*
+setCacheHeaders marks the responses that depend on the viewer, drafts and pages of an identified caller
+are private and shared caches keep the anonymous ones apart from the others by the identity headers
+*/
+func setCacheHeaders(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) {
+	_, identified := callerFromContext(r.Context())
+	w.Header().Add("Vary", HeaderUserId+", "+HeaderUserRole)
+	helper.SetCacheHeaders(w, etag, lastModified, identified)
+}
+
+// viewer is the caller the reads are scoped to, anonymous requests get the zero caller
+func viewer(r *http.Request) entity.Caller {
+	caller, _ := callerFromContext(r.Context())
+	return caller
+}
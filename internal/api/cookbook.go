@@ -0,0 +1,97 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/helper"
+	"github.com/rhnauf/recipe-api/internal/render"
+)
+
+const (
+	defaultCookbookTitle = "Cookbook"
+	maxCookbookRecipes   = 100
+)
+
+/*
+the cookbook is made of the recipes given in ids, e.g. /cookbook?ids=3,1,2&title=Family Favourites,
+in that order, every recipe has to be visible to the caller otherwise the missing ids are reported back
+*/
+func (a *api) getCookbook(w http.ResponseWriter, r *http.Request) {
+	ids, err := parseCookbookIds(r.URL.Query().Get("ids"))
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	title := strings.TrimSpace(r.URL.Query().Get("title"))
+	if title == "" {
+		title = defaultCookbookTitle
+	}
+
+	recipes, err := a.recipeRepository.GetRecipesByIds(r.Context(), viewer(r), ids)
+	if err != nil {
+		writeError(w, r, err, "error getting recipe")
+		return
+	}
+
+	if len(recipes) != len(ids) {
+		found := make(map[int64]bool, len(recipes))
+		for _, recipe := range recipes {
+			found[recipe.Id] = true
+		}
+		var missing []int64
+		for _, id := range ids {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+		helper.HandleProblem(w, r, http.StatusNotFound, "recipe not found", map[string]interface{}{
+			"missing_ids": missing,
+		})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := render.Cookbook(&buf, title, recipes, time.Now()); err != nil {
+		writeError(w, r, err, "error render cookbook")
+		return
+	}
+
+	w.Header().Set("Content-Type", render.PDFMediaType)
+	w.Header().Set("Content-Disposition", `attachment; filename="cookbook.pdf"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// a repeated id only makes it into the cookbook once
+func parseCookbookIds(param string) ([]int64, error) {
+	if strings.TrimSpace(param) == "" {
+		return nil, errors.New("ids must not be empty")
+	}
+
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, part := range strings.Split(param, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 0, 64)
+		if err != nil {
+			return nil, errors.New("ids must be numeric")
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+
+	if len(ids) > maxCookbookRecipes {
+		return nil, fmt.Errorf("a cookbook can have at most %d recipes", maxCookbookRecipes)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func TestGetCookbook(t *testing.T) {
+
+	t.Run("should return 200 with the pdf", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/cookbook?ids=2,1,2&title=Family%20Favourites", nil)
+		rec := httptest.NewRecorder()
+
+		a.getCookbook(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("got %d, want %d", rec.Code, http.StatusOK)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != "application/pdf" {
+			t.Errorf("got Content-Type %q, want %q", contentType, "application/pdf")
+		}
+		if !strings.HasPrefix(rec.Body.String(), "%PDF-") {
+			t.Errorf("want a pdf body")
+		}
+	})
+
+	t.Run("should return 404 with the ids not found", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/cookbook?ids=1,3,4", nil)
+		rec := httptest.NewRecorder()
+
+		a.getCookbook(rec, req)
+
+		var res struct {
+			helper.Problem
+			MissingIds []int64 `json:"missing_ids"`
+		}
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		byteData, _ := json.Marshal(res.MissingIds)
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "recipe not found")
+		if string(byteData) != "[3,4]" {
+			t.Errorf("got %s, want %s", byteData, "[3,4]")
+		}
+	})
+
+	tests := []struct {
+		name    string
+		url     string
+		status  int
+		message string
+	}{
+		{"should return 400 without ids", "/cookbook", http.StatusBadRequest, "ids must not be empty"},
+		{"should return 400 on non numeric ids", "/cookbook?ids=1,x", http.StatusBadRequest, "ids must be numeric"},
+		{"should return 500 error getting recipe", "/cookbook?ids=99", http.StatusInternalServerError, "error getting recipe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			rec := httptest.NewRecorder()
+
+			a.getCookbook(rec, req)
+
+			var res helper.Problem
+			if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+				t.Fatalf("error decoding response body, %v", err.Error())
+			}
+
+			assertStatusCode(t, int32(res.Status), int32(tt.status))
+			assertMessage(t, res.Detail, tt.message)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/logging"
+)
+
+const exportFlushRows = 100
+
+// exportWriter remembers whether anything reached the client, up to then an error can still be answered properly
+type exportWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *exportWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// the rows are written while they are read from the database, once the download started it can only be cut short
+func (a *api) exportRecipesCSV(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="recipes.csv"`)
+
+	out := &exportWriter{ResponseWriter: w}
+	writer := csv.NewWriter(out)
+	_ = writer.Write(entity.RecipeCSVHeader)
+
+	rows := 0
+	err := a.recipeRepository.ExportRecipes(r.Context(), viewer(r), func(recipe *entity.Recipe) error {
+		if err := writer.Write(recipe.CSVRecord()); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			writer.Flush()
+			return writer.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		if !out.written {
+			w.Header().Del("Content-Disposition")
+			writeError(w, r, err, "error export recipe")
+			return
+		}
+		logging.FromContext(r.Context()).Error("error export recipe after the first row", "error", err)
+		return
+	}
+
+	writer.Flush()
+}
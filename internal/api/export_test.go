@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func TestExportRecipesCSV(t *testing.T) {
+
+	url := "/recipe/export.csv"
+
+	t.Run("should return 200 with every recipe as csv", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		rec := httptest.NewRecorder()
+
+		a.exportRecipesCSV(rec, req)
+
+		want := "id,slug,title,description,instruction,status,publish_at,created_at,updated_at\n" +
+			"1,nasi-goreng,nasi goreng,,\"cook rice\nadd egg\",published,,2024-05-01T10:00:00Z,2024-05-01T10:00:00Z\n" +
+			"2,soto-ayam,\"soto ayam, bening\",,,published,,2024-05-01T10:00:00Z,2024-05-01T10:00:00Z\n"
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("got %d, want %d", rec.Code, http.StatusOK)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != "text/csv; charset=utf-8" {
+			t.Errorf("got Content-Type %q, want %q", contentType, "text/csv; charset=utf-8")
+		}
+		if got := rec.Body.String(); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("should return 500 error export recipe before anything is sent", func(t *testing.T) {
+		req := withCaller(httptest.NewRequest(http.MethodGet, url, nil), 99)
+		rec := httptest.NewRecorder()
+
+		a.exportRecipesCSV(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error export recipe")
+		if disposition := rec.Header().Get("Content-Disposition"); disposition != "" {
+			t.Errorf("got Content-Disposition %q, want none", disposition)
+		}
+	})
+}
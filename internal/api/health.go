@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+/*
+	GET /healthz answers as long as the process serves requests, it checks no dependency so an outage
+	of postgres does not get the app restarted, GET /readyz checks the dependencies and answers 503
+	with the failing ones while the app can not serve or is draining before a shutdown
+*/
+
+func (a *api) healthz(w http.ResponseWriter, r *http.Request) {
+	helper.HandleRaw(w, http.StatusOK, "application/json", &entity.HealthDTO{Status: entity.HealthUp})
+}
+
+func (a *api) readyz(w http.ResponseWriter, r *http.Request) {
+	res, ready := a.health.Ready(r.Context())
+
+	statusCode := http.StatusOK
+	if !ready {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	helper.HandleRaw(w, statusCode, "application/json", res)
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/rhnauf/recipe-api/internal/helper"
+	"github.com/rhnauf/recipe-api/internal/importer"
+)
+
+const maxImportSize = 10 << 20
+
+/*
+the upload is the raw html page, json-ld document or csv file, the media type is taken from Content-Type,
+csv columns are mapped with <field>_column query params, e.g. ?title_column=Recipe Name, and dry_run=true
+only validates the rows, the response reports the result of every recipe so a partial import can be fixed
+*/
+func (a *api) importRecipes(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusUnsupportedMediaType, "invalid Content-Type header", nil)
+		return
+	}
+
+	var dryRun bool
+	if dryRunParam := r.URL.Query().Get("dry_run"); dryRunParam != "" {
+		dryRun, err = strconv.ParseBool(dryRunParam)
+		if err != nil {
+			helper.HandleProblem(w, r, http.StatusBadRequest, "dry_run must be a boolean", nil)
+			return
+		}
+	}
+
+	columns := importer.Columns{}
+	for _, field := range importer.CSVFields {
+		if column := r.URL.Query().Get(field + "_column"); column != "" {
+			columns[field] = column
+		}
+	}
+
+	source, err := importer.Parse(mediaType, http.MaxBytesReader(w, r.Body, maxImportSize), columns)
+	if err != nil {
+		if errors.Is(err, importer.ErrUnsupportedMediaType) {
+			helper.HandleProblem(w, r, http.StatusUnsupportedMediaType, "content type must be text/html, text/csv or application/ld+json", nil)
+			return
+		}
+		if errors.Is(err, importer.ErrNoRecipe) || errors.Is(err, importer.ErrMissingColumn) {
+			helper.HandleProblem(w, r, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		helper.HandleProblem(w, r, http.StatusBadRequest, "error parsing import payload", nil)
+		return
+	}
+
+	var ownerId *int64
+	if caller, ok := callerFromContext(r.Context()); ok {
+		ownerId = &caller.Id
+	}
+
+	report, err := a.recipeImporter.Import(r.Context(), source, ownerId, dryRun)
+	if err != nil {
+		// the rows before the broken one have been stored already, the report tells which
+		helper.HandleProblem(w, r, http.StatusBadRequest, "error parsing import payload", map[string]interface{}{
+			"report": report,
+		})
+		return
+	}
+
+	helper.HandleResponse(w, http.StatusOK, "success import recipe", report)
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func TestImportRecipes(t *testing.T) {
+
+	url := "/recipe/import"
+
+	t.Run("should return 200 with the result of every recipe", func(t *testing.T) {
+		page := `<html><head><script type="application/ld+json">[
+			{"@type": "Recipe", "name": "soto ayam"},
+			{"@type": "Recipe", "name": "nasi goreng"},
+			{"@type": "Recipe", "name": "failed"},
+			{"@type": "Recipe"}
+		]</script></head></html>`
+
+		buf := &bytes.Buffer{}
+		previous := slog.Default()
+		slog.SetDefault(slog.New(slog.NewJSONHandler(buf, nil)))
+		defer slog.SetDefault(previous)
+
+		req := withCaller(httptest.NewRequest(http.MethodPost, url, strings.NewReader(page)), 7)
+		req.Header.Set("Content-Type", "text/html; charset=utf-8")
+		rec := httptest.NewRecorder()
+
+		a.importRecipes(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		byteData, _ := json.Marshal(res.Data)
+
+		var got entity.ImportReportDTO
+		_ = json.Unmarshal(byteData, &got)
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success import recipe")
+		if got.Imported != 1 || got.Duplicates != 1 || got.Failed != 1 || got.Invalid != 1 || len(got.Items) != 4 {
+			t.Errorf("got %+v, want one recipe of every result", got)
+		}
+		if got.Items[1].Result != entity.ImportResultDuplicate {
+			t.Errorf("got %q, want %q", got.Items[1].Result, entity.ImportResultDuplicate)
+		}
+		if log := buf.String(); !strings.Contains(log, `"msg":"error insert imported recipe"`) || !strings.Contains(log, `"index":2`) {
+			t.Errorf("got log %s, want the failed insert logged", log)
+		}
+	})
+
+	t.Run("should return 200 with a validation report on csv dry run", func(t *testing.T) {
+		body := "Recipe Name,description,status\n" +
+			"soto ayam,clear soup,in_review\n" +
+			",no title,draft\n" +
+			"rendang,beef,cooked\n" +
+			"gado gado\n"
+
+		req := httptest.NewRequest(http.MethodPost, url+"?dry_run=true&title_column=Recipe%20Name", strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		rec := httptest.NewRecorder()
+
+		a.importRecipes(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		byteData, _ := json.Marshal(res.Data)
+
+		var got entity.ImportReportDTO
+		_ = json.Unmarshal(byteData, &got)
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success import recipe")
+		if got.Valid != 1 || got.Invalid != 3 || got.Imported != 0 {
+			t.Errorf("got %+v, want one valid and three invalid rows", got)
+		}
+		if got.Items[1].Error != "title must not be empty" {
+			t.Errorf("got %q, want %q", got.Items[1].Error, "title must not be empty")
+		}
+	})
+
+	t.Run("should return 200 importing an exported file as is", func(t *testing.T) {
+		body := strings.Join(entity.RecipeCSVHeader, ",") + "\n" +
+			"3,soto-ayam,soto ayam,clear soup,boil chicken,published,,2024-05-01T10:00:00Z,2024-05-01T10:00:00Z\n" +
+			"4,rendang,rendang,beef,slow cook,archived,,2024-05-01T10:00:00Z,2024-05-01T10:00:00Z\n"
+
+		for _, tt := range []struct {
+			name     string
+			req      *http.Request
+			imported int
+		}{
+			{"author", withCaller(httptest.NewRequest(http.MethodPost, url, strings.NewReader(body)), 7), 2},
+			{"anonymous", httptest.NewRequest(http.MethodPost, url, strings.NewReader(body)), 0},
+		} {
+			tt.req.Header.Set("Content-Type", "text/csv")
+			rec := httptest.NewRecorder()
+
+			a.importRecipes(rec, tt.req)
+
+			var res helper.Response
+			if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+				t.Fatalf("error decoding response body, %v", err.Error())
+			}
+
+			byteData, _ := json.Marshal(res.Data)
+
+			var got entity.ImportReportDTO
+			_ = json.Unmarshal(byteData, &got)
+
+			if got.Imported != tt.imported || got.Imported+got.Invalid != 2 {
+				t.Errorf("got %+v for the %s, want %d imported", got, tt.name, tt.imported)
+			}
+		}
+	})
+
+	t.Run("should return 400 when the mapped csv column is missing", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, url+"?title_column=Recipe", strings.NewReader("title\nsoto ayam\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		rec := httptest.NewRecorder()
+
+		a.importRecipes(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "csv column not found: Recipe")
+	})
+
+	t.Run("should return 415 on unsupported content type", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader("title,description"))
+		req.Header.Set("Content-Type", "text/plain")
+		rec := httptest.NewRecorder()
+
+		a.importRecipes(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusUnsupportedMediaType)
+	})
+
+	t.Run("should return 400 on invalid json-ld", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader("{"))
+		req.Header.Set("Content-Type", "application/ld+json")
+		rec := httptest.NewRecorder()
+
+		a.importRecipes(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "error parsing import payload")
+	})
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+// the export is the plain schema.org document so it can be embedded in a page as is
+func (a *api) getRecipeJSONLD(w http.ResponseWriter, r *http.Request) {
+	pathParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(pathParam, 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
+		return
+	}
+
+	recipe, err := a.recipeRepository.GetRecipeById(r.Context(), viewer(r), id)
+	if err != nil {
+		writeError(w, r, notFound(err, "recipe not found"), "error getting recipe")
+		return
+	}
+
+	writeRecipeJSONLD(w, r, recipe)
+}
+
+func writeRecipeJSONLD(w http.ResponseWriter, r *http.Request, recipe *entity.Recipe) {
+	etag := helper.VariantETag(recipe.Version, "jsonld")
+	setCacheHeaders(w, r, etag, recipe.UpdatedAt)
+	if helper.IsNotModified(r, etag, recipe.UpdatedAt) {
+		helper.HandleNotModified(w)
+		return
+	}
+
+	helper.HandleRaw(w, http.StatusOK, entity.JSONLDMediaType, entity.NewRecipeJSONLD(recipe))
+}
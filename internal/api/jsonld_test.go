@@ -0,0 +1,94 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func TestGetRecipeJSONLD(t *testing.T) {
+
+	url := "/recipe/{id}/jsonld"
+
+	t.Run("should return 200 schema.org recipe", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "1"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeJSONLD(rec, req)
+
+		var got entity.RecipeJSONLD
+		if err := json.NewDecoder(rec.Result().Body).Decode(&got); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("got %d, want %d", rec.Code, http.StatusOK)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != entity.JSONLDMediaType {
+			t.Errorf("got Content-Type %q, want %q", contentType, entity.JSONLDMediaType)
+		}
+		if got.Context != entity.SchemaOrgContext || got.Type != "Recipe" || got.Name != "nasi goreng" {
+			t.Errorf("got %v, want the schema.org recipe of nasi goreng", got)
+		}
+		if etag := rec.Header().Get("ETag"); etag != `"1+jsonld"` {
+			t.Errorf("got ETag %q, want %q", etag, `"1+jsonld"`)
+		}
+	})
+
+	t.Run("should return 404 recipe not found", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "0"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeJSONLD(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "recipe not found")
+	})
+}
+
+func TestGetRecipeByIdNegotiation(t *testing.T) {
+
+	url := "/recipe/{id}"
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+	}{
+		{"should return json without accept header", "", "application/json"},
+		{"should return json for any media type", "*/*", "application/json"},
+		{"should return json-ld when asked for", "application/ld+json", entity.JSONLDMediaType},
+		{"should return json-ld when preferred", "application/json;q=0.5, application/ld+json", entity.JSONLDMediaType},
+		{"should return markdown when asked for", "text/markdown", "text/markdown; charset=utf-8"},
+		{"should return html for browsers", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html; charset=utf-8"},
+		{"should fall back to json for unknown media types", "text/csv", "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "1"})
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			a.getRecipeById(rec, req)
+
+			if contentType := rec.Header().Get("Content-Type"); contentType != tt.contentType {
+				t.Errorf("got Content-Type %q, want %q", contentType, tt.contentType)
+			}
+			if vary := rec.Header().Get("Vary"); vary != "Accept" {
+				t.Errorf("got Vary %q, want %q", vary, "Accept")
+			}
+		})
+	}
+}
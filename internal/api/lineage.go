@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func (a *api) forkRecipe(w http.ResponseWriter, r *http.Request) {
+	caller, ok := callerFromContext(r.Context())
+	if !ok {
+		helper.HandleProblem(w, r, http.StatusUnauthorized, "user id is required", nil)
+		return
+	}
+
+	pathParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(pathParam, 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
+		return
+	}
+
+	// the payload is optional, an empty body forks the recipe with a generated title
+	var requestFork entity.ForkRecipeDTO
+	if err := json.NewDecoder(r.Body).Decode(&requestFork); err != nil && !errors.Is(err, io.EOF) {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "error decoding request payload", nil)
+		return
+	}
+
+	if err := requestFork.Validate(); err != nil {
+		writeError(w, r, err, "error fork recipe")
+		return
+	}
+
+	var title string
+	if requestFork.Title != nil {
+		title = *requestFork.Title
+	}
+
+	forkId, err := a.recipeRepository.ForkRecipe(r.Context(), caller, id, title)
+	if err != nil {
+		writeError(w, r, notFound(err, "recipe not found"), "error fork recipe")
+		return
+	}
+
+	recipeDto := entity.RecipeDTO{
+		Id:           forkId,
+		OwnerId:      &caller.Id,
+		ForkedFromId: &id,
+	}
+
+	helper.HandleResponse(w, http.StatusOK, "success fork recipe", recipeDto)
+}
+
+func (a *api) getRecipeLineage(w http.ResponseWriter, r *http.Request) {
+	pathParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(pathParam, 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
+		return
+	}
+
+	recipe, err := a.recipeRepository.GetRecipeById(r.Context(), viewer(r), id)
+	if err != nil {
+		writeError(w, r, notFound(err, "recipe not found"), "error getting recipe")
+		return
+	}
+
+	ancestors, err := a.recipeRepository.GetRecipeAncestors(r.Context(), viewer(r), id)
+	if err != nil {
+		writeError(w, r, err, "error get recipe lineage")
+		return
+	}
+
+	forks, err := a.recipeRepository.GetRecipeForks(r.Context(), viewer(r), id)
+	if err != nil {
+		writeError(w, r, err, "error get recipe lineage")
+		return
+	}
+
+	res := entity.LineageDTO{
+		Recipe: entity.LineageNodeDTO{
+			Id:           recipe.Id,
+			Title:        recipe.Title,
+			OwnerId:      recipe.OwnerId,
+			ForkedFromId: recipe.ForkedFromId,
+		},
+		Ancestors: make([]*entity.LineageNodeDTO, len(ancestors)),
+		Forks:     make([]*entity.LineageNodeDTO, len(forks)),
+	}
+	for idx, node := range ancestors {
+		res.Ancestors[idx] = node.ToDTO()
+	}
+	for idx, node := range forks {
+		res.Forks[idx] = node.ToDTO()
+	}
+
+	helper.HandleResponse(w, http.StatusOK, "success get recipe lineage", res)
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func withCaller(r *http.Request, userId int64) *http.Request {
+	caller := entity.Caller{Id: userId}
+	return r.WithContext(contextWithCaller(r.Context(), caller))
+}
+
+func TestForkRecipe(t *testing.T) {
+
+	url := "/recipe/1/fork"
+
+	idSuccess := map[string]string{
+		"id": "1",
+	}
+	idNotFound := map[string]string{
+		"id": "0",
+	}
+	idError := map[string]string{
+		"id": "2",
+	}
+
+	t.Run("should return 200 success fork recipe", func(t *testing.T) {
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, nil), idSuccess), 7)
+		rec := httptest.NewRecorder()
+
+		a.forkRecipe(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success fork recipe")
+		assertNotNil(t, res.Data)
+	})
+
+	t.Run("should return 401 when caller is anonymous", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodPost, url, nil), idSuccess)
+		rec := httptest.NewRecorder()
+
+		a.forkRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusUnauthorized)
+		assertMessage(t, res.Detail, "user id is required")
+	})
+
+	t.Run("should return 422 error validating the given title", func(t *testing.T) {
+		for _, body := range []string{`{"title": ""}`, `{"title": "` + strings.Repeat("a", entity.MaxTitleLength+1) + `"}`} {
+			req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, strings.NewReader(body)), idSuccess), 7)
+			rec := httptest.NewRecorder()
+
+			a.forkRecipe(rec, req)
+
+			var res helper.Problem
+			if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+				t.Fatalf("error decoding response body, %v", err.Error())
+			}
+
+			assertStatusCode(t, int32(res.Status), http.StatusUnprocessableEntity)
+		}
+	})
+
+	t.Run("should return 400 error decode payload", func(t *testing.T) {
+		body := []byte(`invalid body`)
+
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, bytes.NewBuffer(body)), idSuccess), 7)
+		rec := httptest.NewRecorder()
+
+		a.forkRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "error decoding request payload")
+	})
+
+	t.Run("should return 404 error recipe not found", func(t *testing.T) {
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, nil), idNotFound), 7)
+		rec := httptest.NewRecorder()
+
+		a.forkRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "recipe not found")
+	})
+
+	t.Run("should return 500 error fork recipe", func(t *testing.T) {
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, nil), idError), 7)
+		rec := httptest.NewRecorder()
+
+		a.forkRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error fork recipe")
+	})
+}
+
+func TestGetRecipeLineage(t *testing.T) {
+
+	url := "/recipe/1/lineage"
+
+	idSuccess := map[string]string{
+		"id": "1",
+	}
+	idNotFound := map[string]string{
+		"id": "0",
+	}
+
+	t.Run("should return 200 success get recipe lineage", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), idSuccess)
+		rec := httptest.NewRecorder()
+
+		a.getRecipeLineage(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		byteData, _ := json.Marshal(res.Data)
+
+		var got entity.LineageDTO
+		_ = json.Unmarshal(byteData, &got)
+
+		var parentId int64 = 1
+		want := entity.LineageDTO{
+			Recipe:    entity.LineageNodeDTO{Id: 1, Title: "nasi goreng", OwnerId: &mockOwnerId},
+			Ancestors: []*entity.LineageNodeDTO{},
+			Forks: []*entity.LineageNodeDTO{
+				{Id: 2, Title: "nasi goreng (fork 1)", ForkedFromId: &parentId, Depth: 1},
+			},
+		}
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success get recipe lineage")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("should return 404 error recipe not found", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), idNotFound)
+		rec := httptest.NewRecorder()
+
+		a.getRecipeLineage(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "recipe not found")
+	})
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+	"github.com/rhnauf/recipe-api/internal/logging"
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+/*
+writeError logs err with the request and answers with the problem matching the kind of the
+domain error, a query that ran out of time is a 503 and anything else is a failure of the server,
+the client only gets the fallback detail of those two while the log keeps the cause
+*/
+func writeError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	statusCode, detail := http.StatusInternalServerError, fallback
+	var extensions map[string]interface{}
+
+	var validationErr *entity.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		statusCode, detail = http.StatusUnprocessableEntity, validationErr.Error()
+		extensions = map[string]interface{}{"errors": validationErr.Fields}
+	case errors.Is(err, entity.ErrNotFound):
+		statusCode, detail = http.StatusNotFound, err.Error()
+	case errors.Is(err, entity.ErrConflict):
+		statusCode, detail = http.StatusConflict, err.Error()
+	case errors.Is(err, entity.ErrPrecondition):
+		statusCode, detail = http.StatusPreconditionFailed, err.Error()
+	case repository.IsTimeout(err):
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	level := slog.LevelInfo
+	if statusCode >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logging.FromContext(r.Context()).Log(r.Context(), level, fallback, "error", err, "status", statusCode)
+
+	helper.HandleProblem(w, r, statusCode, detail, extensions)
+}
+
+// notFound turns the sql.ErrNoRows of the repository into a not found error naming what is missing
+func notFound(err error, message string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.NewError(entity.ErrNotFound, message)
+	}
+	return err
+}
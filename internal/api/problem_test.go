@@ -0,0 +1,49 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCode  int
+		wantLevel string
+	}{
+		{"should log a failure of the server as an error", sql.ErrConnDone, http.StatusInternalServerError, "ERROR"},
+		{"should log a domain error as info", entity.NewError(entity.ErrNotFound, "recipe not found"), http.StatusNotFound, "INFO"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			previous := slog.Default()
+			slog.SetDefault(slog.New(slog.NewJSONHandler(buf, nil)))
+			defer slog.SetDefault(previous)
+
+			rec := httptest.NewRecorder()
+			writeError(rec, httptest.NewRequest(http.MethodGet, "/recipe/1", nil), tt.err, "error get recipe")
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("error decoding log, %v", err)
+			}
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("got %d, want %d", rec.Code, tt.wantCode)
+			}
+			if entry["level"] != tt.wantLevel || entry["msg"] != "error get recipe" || entry["error"] != tt.err.Error() || entry["status"] != float64(tt.wantCode) {
+				t.Errorf("got %v, want the error logged at %s", entry, tt.wantLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rhnauf/recipe-api/internal/ratelimit"
+)
+
+const HeaderAPIKey = "X-Api-Key"
+
+/*
+NewRateLimitKey names the client a request counts against, its api key once verified against the digests
+of the issued keys, else the user forwarded by the gateway, else its ip. An unknown api key is ignored so a
+client can not dodge its limit by sending a new key with every request, and only a digest of the key is held.
+Like X-User-Id, the X-Forwarded-For of the gateway is trusted, the gateway appends the ip it saw so the
+rightmost entry is the one a client can not forge
+*/
+func NewRateLimitKey(apiKeyHashes []string) ratelimit.KeyFunc {
+	issued := make(map[string]bool, len(apiKeyHashes))
+	for _, hash := range apiKeyHashes {
+		issued[hash] = true
+	}
+
+	return func(r *http.Request) string {
+		if apiKey := r.Header.Get(HeaderAPIKey); apiKey != "" {
+			sum := sha256.Sum256([]byte(apiKey))
+			if hash := hex.EncodeToString(sum[:]); issued[hash] {
+				return "key:" + hash[:32]
+			}
+		}
+
+		if caller, ok := callerFromContext(r.Context()); ok {
+			return "user:" + strconv.FormatInt(caller.Id, 10)
+		}
+
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(entries[len(entries)-1]); ip != "" {
+				return "ip:" + ip
+			}
+		}
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		return "ip:" + ip
+	}
+}
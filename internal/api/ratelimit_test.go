@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func TestRateLimitKey(t *testing.T) {
+	// the digest of the api key "secret"
+	rateLimitKey := NewRateLimitKey([]string{"2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"})
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		caller *entity.Caller
+		want   string
+	}{
+		{"should prefer an issued api key", map[string]string{HeaderAPIKey: "secret", "X-Forwarded-For": "10.0.0.1"}, &entity.Caller{Id: 7}, "key:2bb80d537b1da3e38bd30361aa855686"},
+		{"should ignore an unknown api key", map[string]string{HeaderAPIKey: "forged", "X-Forwarded-For": "10.0.0.1"}, &entity.Caller{Id: 7}, "user:7"},
+		{"should fall back to the user", map[string]string{"X-Forwarded-For": "10.0.0.1"}, &entity.Caller{Id: 7}, "user:7"},
+		{"should take the forwarded ip appended by the gateway", map[string]string{"X-Forwarded-For": "10.0.0.1, 10.0.0.2"}, nil, "ip:10.0.0.2"},
+		{"should fall back to the remote address", nil, nil, "ip:192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/recipe", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			if tt.caller != nil {
+				req = req.WithContext(contextWithCaller(req.Context(), *tt.caller))
+			}
+
+			got := rateLimitKey(req)
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,39 +1,55 @@
 package api
 
 import (
-	"database/sql"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/rhnauf/recipe-api/internal/entity"
 	"github.com/rhnauf/recipe-api/internal/helper"
+	"github.com/rhnauf/recipe-api/internal/render"
 )
 
 func (a *api) insertRecipe(w http.ResponseWriter, r *http.Request) {
 	var requestRecipe entity.RecipeDTO
 	if err := json.NewDecoder(r.Body).Decode(&requestRecipe); err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, "error decoding request payload", nil)
+		helper.HandleProblem(w, r, http.StatusBadRequest, "error decoding request payload", nil)
 		return
 	}
 
 	if err := requestRecipe.InsertValidate(); err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, err.Error(), nil)
+		writeError(w, r, err, "error insert recipe")
 		return
 	}
 
+	// the payload has been validated, publish_at is known to parse
+	publishAt, _ := requestRecipe.PublishAtTime()
+
 	recipe := entity.Recipe{
 		Title:       requestRecipe.Title,
 		Description: requestRecipe.Description,
 		Instruction: requestRecipe.Instruction,
-		Publish:     requestRecipe.Publish,
+		Status:      requestRecipe.TargetStatus(entity.StatusDraft),
+		PublishAt:   publishAt,
+	}
+	caller, identified := callerFromContext(r.Context())
+	if identified {
+		recipe.OwnerId = &caller.Id
+	}
+	// creating a published recipe walks it through review on behalf of its author, so like a transition it needs one
+	if entity.IsPastReview(recipe.Status) && !identified {
+		helper.HandleProblem(w, r, http.StatusUnauthorized, "user id is required", nil)
+		return
 	}
 
-	if err := a.recipeRepository.InsertRecipe(recipe); err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, "error insert recipe", nil)
+	if err := a.recipeRepository.InsertRecipe(r.Context(), recipe); err != nil {
+		writeError(w, r, err, "error insert recipe")
 		return
 	}
 
@@ -43,36 +59,81 @@ func (a *api) insertRecipe(w http.ResponseWriter, r *http.Request) {
 func (a *api) updateRecipe(w http.ResponseWriter, r *http.Request) {
 	var requestRecipe entity.RecipeDTO
 	if err := json.NewDecoder(r.Body).Decode(&requestRecipe); err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, "error decoding request payload", nil)
+		helper.HandleProblem(w, r, http.StatusBadRequest, "error decoding request payload", nil)
 		return
 	}
 
 	pathParam := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(pathParam, 0, 64)
 	if err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, "id must be numeric", nil)
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
 		return
 	}
 	requestRecipe.SetId(id)
 
+	version, err := helper.ParseIfMatch(r.Header.Get("If-Match"))
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "invalid If-Match header", nil)
+		return
+	}
+
 	if err := requestRecipe.UpdateValidate(); err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, err.Error(), nil)
+		writeError(w, r, err, "error update recipe")
 		return
 	}
 
+	publishAt, _ := requestRecipe.PublishAtTime()
+
 	recipe := entity.Recipe{
 		Id:          requestRecipe.Id,
 		Title:       requestRecipe.Title,
 		Description: requestRecipe.Description,
 		Instruction: requestRecipe.Instruction,
-		Publish:     requestRecipe.Publish,
+		Version:     version,
+		PublishAt:   publishAt,
+	}
+
+	caller, identified := callerFromContext(r.Context())
+	var editedBy *int64
+	if identified {
+		editedBy = &caller.Id
 	}
 
-	if err := a.recipeRepository.UpdateRecipe(recipe); err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, "error update recipe", nil)
+	// a requested status change is checked before editing and then written together with the edit, like a transition it needs an identified caller
+	var currentStatus string
+	if requestRecipe.Status != "" || requestRecipe.Publish != nil {
+		current, err := a.recipeRepository.GetRecipeById(r.Context(), viewer(r), id)
+		if err != nil {
+			writeError(w, r, notFound(err, "recipe not found"), "error update recipe")
+			return
+		}
+
+		currentStatus = current.Status
+		if targetStatus := requestRecipe.TargetStatus(currentStatus); targetStatus != currentStatus {
+			if !identified {
+				helper.HandleProblem(w, r, http.StatusUnauthorized, "user id is required", nil)
+				return
+			}
+			if !caller.CanManage(current.OwnerId) {
+				helper.HandleProblem(w, r, http.StatusForbidden, "only the author or an editor can move the recipe", nil)
+				return
+			}
+			// the legacy publish flag walks through review when needed, an explicit status is a single move
+			if !entity.CanTransition(currentStatus, targetStatus) && (requestRecipe.Status != "" || entity.TransitionPath(currentStatus, targetStatus) == nil) {
+				helper.HandleProblem(w, r, http.StatusConflict, entity.TransitionNotAllowed(currentStatus, targetStatus).Error(), nil)
+				return
+			}
+			recipe.Status = targetStatus
+		}
+	}
+
+	newVersion, err := a.recipeRepository.UpdateRecipe(r.Context(), recipe, currentStatus, editedBy)
+	if err != nil {
+		writeError(w, r, notFound(err, "recipe not found"), "error update recipe")
 		return
 	}
 
+	w.Header().Set("ETag", helper.ETag(newVersion))
 	helper.HandleResponse(w, http.StatusOK, "success update recipe", nil)
 }
 
@@ -80,29 +141,47 @@ func (a *api) getRecipeById(w http.ResponseWriter, r *http.Request) {
 	pathParam := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(pathParam, 0, 64)
 	if err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, "id must be numeric", nil)
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
 		return
 	}
 
-	recipe, err := a.recipeRepository.GetRecipeById(id)
+	recipe, err := a.recipeRepository.GetRecipeById(r.Context(), viewer(r), id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			helper.HandleResponse(w, http.StatusBadRequest, "recipe not found", nil)
-			return
-		}
-		helper.HandleResponse(w, http.StatusBadRequest, "error getting recipe", nil)
+		writeError(w, r, notFound(err, "recipe not found"), "error getting recipe")
 		return
 	}
 
-	recipeDto := entity.RecipeDTO{
-		Id:          recipe.Id,
-		Title:       recipe.Title,
-		Description: recipe.Description,
-		Instruction: recipe.Instruction,
-		Publish:     recipe.Publish,
-		CreatedAt:   recipe.CreatedAt.Format("02-01-2006"),
+	writeRecipe(w, r, recipe)
+}
+
+/*
+writeRecipe answers with the detail of a recipe in the representation the client accepts,
+honouring the conditional request headers, unknown media types get the default json
+*/
+func writeRecipe(w http.ResponseWriter, r *http.Request, recipe *entity.Recipe) {
+	w.Header().Add("Vary", "Accept")
+
+	switch helper.Negotiate(r, "application/json", entity.JSONLDMediaType, render.MarkdownMediaType, render.HTMLMediaType) {
+	case entity.JSONLDMediaType:
+		writeRecipeJSONLD(w, r, recipe)
+		return
+	case render.MarkdownMediaType:
+		writeRecipePage(w, r, recipe, "md", render.MarkdownMediaType, render.Markdown)
+		return
+	case render.HTMLMediaType:
+		writeRecipePage(w, r, recipe, "html", render.HTMLMediaType, render.HTML)
+		return
+	}
+
+	etag := helper.ETag(recipe.Version)
+	setCacheHeaders(w, r, etag, recipe.UpdatedAt)
+	if helper.IsNotModified(r, etag, recipe.UpdatedAt) {
+		helper.HandleNotModified(w)
+		return
 	}
 
+	recipeDto := entity.NewRecipeDTO(recipe)
+
 	helper.HandleResponse(w, http.StatusOK, "success get detail recipe", recipeDto)
 }
 
@@ -110,13 +189,19 @@ func (a *api) deleteRecipeById(w http.ResponseWriter, r *http.Request) {
 	pathParam := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(pathParam, 0, 64)
 	if err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, "id must be numeric", nil)
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
 		return
 	}
 
-	err = a.recipeRepository.DeleteRecipeById(id)
+	version, err := helper.ParseIfMatch(r.Header.Get("If-Match"))
 	if err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, "error delete recipe", nil)
+		helper.HandleProblem(w, r, http.StatusBadRequest, "invalid If-Match header", nil)
+		return
+	}
+
+	err = a.recipeRepository.DeleteRecipeById(r.Context(), id, version)
+	if err != nil {
+		writeError(w, r, notFound(err, "recipe not found"), "error delete recipe")
 		return
 	}
 
@@ -132,18 +217,17 @@ const (
 	MaxLimit     = 100
 )
 
-func (a *api) getListRecipe(w http.ResponseWriter, r *http.Request) {
+func parsePagination(r *http.Request) (limit, offset int64, err error) {
 	pageParam := r.URL.Query().Get("page")
 	limitParam := r.URL.Query().Get("limit")
 
 	var page int64 = DefaultPage
-	var limit int64 = DefaultLimit
+	limit = DefaultLimit
 
 	if pageParam != "" {
 		p, err := strconv.ParseInt(pageParam, 0, 64)
 		if err != nil {
-			helper.HandleResponse(w, http.StatusBadRequest, "page must be numeric", nil)
-			return
+			return 0, 0, errors.New("page must be numeric")
 		}
 		if p >= MinPage {
 			page = p
@@ -153,19 +237,40 @@ func (a *api) getListRecipe(w http.ResponseWriter, r *http.Request) {
 	if limitParam != "" {
 		l, err := strconv.ParseInt(limitParam, 0, 64)
 		if err != nil {
-			helper.HandleResponse(w, http.StatusBadRequest, "limit must be numeric", nil)
-			return
+			return 0, 0, errors.New("limit must be numeric")
 		}
 		if l >= MinLimit && l <= MaxLimit {
 			limit = l
 		}
 	}
 
-	offset := limit * (page - 1)
+	return limit, limit * (page - 1), nil
+}
+
+func (a *api) getListRecipe(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
 
-	recipes, err := a.recipeRepository.GetListRecipe(limit, offset)
+	recipes, err := a.recipeRepository.GetListRecipe(r.Context(), viewer(r), limit, offset)
 	if err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, "error get list recipe", nil)
+		writeError(w, r, err, "error get list recipe")
+		return
+	}
+
+	// the page is identified by its recipes and their versions, any edit or shift of the page changes the tag,
+	// it has no Last-Modified as a recipe deleted from the page shifts an older one in without a newer date
+	hash := sha256.New()
+	for _, recipe := range recipes {
+		fmt.Fprintf(hash, "%d:%d;", recipe.Id, recipe.Version)
+	}
+
+	etag := helper.ListETag(hash.Sum(nil))
+	setCacheHeaders(w, r, etag, time.Time{})
+	if helper.IsNotModified(r, etag, time.Time{}) {
+		helper.HandleNotModified(w)
 		return
 	}
 
@@ -174,6 +279,7 @@ func (a *api) getListRecipe(w http.ResponseWriter, r *http.Request) {
 		res[idx] = &entity.RecipeDTO{
 			Id:    recipe.Id,
 			Title: recipe.Title,
+			Slug:  recipe.Slug,
 		}
 	}
 
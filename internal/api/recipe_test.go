@@ -8,59 +8,251 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/rhnauf/recipe-api/internal/entity"
 	"github.com/rhnauf/recipe-api/internal/helper"
+	"github.com/rhnauf/recipe-api/internal/importer"
+	"github.com/rhnauf/recipe-api/internal/repository"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 type mockRecipeRepository struct{}
 
-func (m *mockRecipeRepository) InsertRecipe(recipe entity.Recipe) error {
+func (m *mockRecipeRepository) InsertRecipe(ctx context.Context, recipe entity.Recipe) error {
 	if recipe.Title == "failed" {
 		return sql.ErrConnDone
+	} else if recipe.Title == "nasi goreng" {
+		return repository.ErrDuplicateTitle
 	}
 	return nil
 }
 
-func (m *mockRecipeRepository) UpdateRecipe(recipe entity.Recipe) error {
+func (m *mockRecipeRepository) UpdateRecipe(ctx context.Context, recipe entity.Recipe, fromStatus string, editedBy *int64) (int64, error) {
 	if recipe.Title == "failed" {
-		return sql.ErrConnDone
+		return 0, sql.ErrConnDone
 	}
-	return nil
+	if recipe.Version != 0 && recipe.Version != 1 {
+		return 0, repository.ErrVersionConflict
+	}
+	return 2, nil
 }
 
-func (m *mockRecipeRepository) GetRecipeById(id int64) (*entity.Recipe, error) {
+var mockOwnerId int64 = 7
+
+func (m *mockRecipeRepository) GetRecipeById(ctx context.Context, viewer entity.Caller, id int64) (*entity.Recipe, error) {
 	if id == 1 {
 		return &entity.Recipe{
-			Id:    1,
-			Title: "nasi goreng",
+			Id:        1,
+			Title:     "nasi goreng",
+			Status:    entity.StatusPublished,
+			OwnerId:   &mockOwnerId,
+			Version:   1,
+			UpdatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
 		}, nil
 	} else if id == 0 {
 		return nil, sql.ErrNoRows
+	} else if id == 4 {
+		// a draft is hidden from viewers outside the editorial team
+		if !viewer.CanSeeDrafts() {
+			return nil, sql.ErrNoRows
+		}
+		return &entity.Recipe{
+			Id:     4,
+			Title:  "nasi goreng draft",
+			Status: entity.StatusDraft,
+		}, nil
 	}
 	return nil, sql.ErrConnDone
 }
 
-func (m *mockRecipeRepository) DeleteRecipeById(id int64) error {
+func (m *mockRecipeRepository) GetRecipeBySlug(ctx context.Context, viewer entity.Caller, slug string) (*entity.Recipe, error) {
+	if slug == "nasi-goreng" {
+		return &entity.Recipe{
+			Id:        1,
+			Title:     "nasi goreng",
+			Slug:      "nasi-goreng",
+			Status:    entity.StatusPublished,
+			Version:   1,
+			UpdatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		}, nil
+	} else if slug == "error" {
+		return nil, sql.ErrConnDone
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockRecipeRepository) GetRecipeSlugRedirect(ctx context.Context, viewer entity.Caller, slug string) (string, error) {
+	if slug == "nasi-goreng-biasa" {
+		return "nasi-goreng", nil
+	}
+	return "", sql.ErrNoRows
+}
+
+func (m *mockRecipeRepository) DeleteRecipeById(ctx context.Context, id, version int64) error {
 	if id == 1 {
+		if version != 0 && version != 1 {
+			return repository.ErrVersionConflict
+		}
 		return nil
+	} else if id == 0 {
+		return sql.ErrNoRows
 	}
 	return sql.ErrConnDone
 }
 
-func (m *mockRecipeRepository) GetListRecipe(limit, offset int64) ([]*entity.Recipe, error) {
+func (m *mockRecipeRepository) GetListRecipe(ctx context.Context, viewer entity.Caller, limit, offset int64) ([]*entity.Recipe, error) {
 	if limit == 10 && offset == 0 {
 		return []*entity.Recipe{
 			{
-				Id:    1,
-				Title: "nasi goreng",
+				Id:        1,
+				Title:     "nasi goreng",
+				Version:   1,
+				UpdatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
 			},
 		}, nil
 	}
+	if limit == 99 {
+		return nil, context.DeadlineExceeded
+	}
+	return nil, sql.ErrConnDone
+}
+
+func (m *mockRecipeRepository) ForkRecipe(ctx context.Context, viewer entity.Caller, id int64, title string) (int64, error) {
+	if id == 1 {
+		return 2, nil
+	} else if id == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return 0, sql.ErrConnDone
+}
+
+func (m *mockRecipeRepository) GetRecipeAncestors(ctx context.Context, viewer entity.Caller, id int64) ([]*entity.LineageNode, error) {
+	if id == 1 {
+		return nil, nil
+	}
+	return nil, sql.ErrConnDone
+}
+
+func (m *mockRecipeRepository) GetRecipeForks(ctx context.Context, viewer entity.Caller, id int64) ([]*entity.LineageNode, error) {
+	if id == 1 {
+		var parentId int64 = 1
+		return []*entity.LineageNode{
+			{Id: 2, Title: "nasi goreng (fork 1)", ForkedFromId: &parentId, Depth: 1},
+		}, nil
+	}
+	return nil, sql.ErrConnDone
+}
+
+func (m *mockRecipeRepository) GetRecipeRevisions(ctx context.Context, viewer entity.Caller, recipeId int64) ([]*entity.Revision, error) {
+	if recipeId == 1 {
+		return []*entity.Revision{
+			{RecipeId: 1, Revision: 2, Title: "nasi goreng spesial"},
+			{RecipeId: 1, Revision: 1, Title: "nasi goreng"},
+		}, nil
+	}
+	return nil, sql.ErrConnDone
+}
+
+func (m *mockRecipeRepository) GetRecipeRevision(ctx context.Context, viewer entity.Caller, recipeId, revision int64) (*entity.Revision, error) {
+	if recipeId != 1 {
+		return nil, sql.ErrConnDone
+	}
+	switch revision {
+	case 1:
+		return &entity.Revision{RecipeId: 1, Revision: 1, Title: "nasi goreng", Instruction: "cook rice\nadd egg"}, nil
+	case 2:
+		return &entity.Revision{RecipeId: 1, Revision: 2, Title: "nasi goreng", Instruction: "cook rice\nadd chicken"}, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *mockRecipeRepository) RestoreRecipeRevision(ctx context.Context, restoredBy entity.Caller, recipeId, revision int64) (int64, error) {
+	if recipeId != 1 {
+		return 0, sql.ErrConnDone
+	}
+	if revision > 2 {
+		return 0, sql.ErrNoRows
+	}
+	return 3, nil
+}
+
+func (m *mockRecipeRepository) GetListDeletedRecipe(ctx context.Context, ownerId *int64, limit, offset int64) ([]*entity.Recipe, error) {
+	if ownerId == nil {
+		return nil, sql.ErrConnDone
+	}
+	deletedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	return []*entity.Recipe{
+		{Id: 1, Title: "nasi goreng", OwnerId: ownerId, DeletedAt: &deletedAt},
+	}, nil
+}
+
+func (m *mockRecipeRepository) RestoreDeletedRecipe(ctx context.Context, id int64, ownerId *int64) error {
+	if id == 1 {
+		return nil
+	} else if id == 0 {
+		return sql.ErrNoRows
+	}
+	return sql.ErrConnDone
+}
+
+func (m *mockRecipeRepository) PurgeDeletedRecipes(ctx context.Context, retention time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockRecipeRepository) TransitionRecipeStatus(ctx context.Context, id int64, from, to string, changedBy *int64) (int64, error) {
+	if id == 1 {
+		return 2, nil
+	}
+	return 0, sql.ErrConnDone
+}
+
+func (m *mockRecipeRepository) GetRecipeStatusTransitions(ctx context.Context, viewer entity.Caller, id int64) ([]*entity.StatusTransition, error) {
+	if id == 1 {
+		return []*entity.StatusTransition{
+			{RecipeId: 1, FromStatus: entity.StatusDraft, ToStatus: entity.StatusInReview},
+			{RecipeId: 1, FromStatus: entity.StatusInReview, ToStatus: entity.StatusPublished},
+		}, nil
+	}
 	return nil, sql.ErrConnDone
 }
 
+func (m *mockRecipeRepository) PublishDueRecipes(ctx context.Context, limit int) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockRecipeRepository) GetRecipesByIds(ctx context.Context, viewer entity.Caller, ids []int64) ([]*entity.Recipe, error) {
+	var recipes []*entity.Recipe
+	for _, id := range ids {
+		if id == 99 {
+			return nil, sql.ErrConnDone
+		}
+		if id == 1 || id == 2 {
+			recipes = append(recipes, &entity.Recipe{Id: id, Title: "recipe " + strconv.FormatInt(id, 10), Instruction: "cook"})
+		}
+	}
+	return recipes, nil
+}
+
+func (m *mockRecipeRepository) ExportRecipes(ctx context.Context, viewer entity.Caller, fn func(*entity.Recipe) error) error {
+	if viewer.Id == 99 {
+		return sql.ErrConnDone
+	}
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	recipes := []*entity.Recipe{
+		{Id: 1, Slug: "nasi-goreng", Title: "nasi goreng", Instruction: "cook rice\nadd egg", Status: entity.StatusPublished, CreatedAt: createdAt, UpdatedAt: createdAt},
+		{Id: 2, Slug: "soto-ayam", Title: "soto ayam, bening", Status: entity.StatusPublished, CreatedAt: createdAt, UpdatedAt: createdAt},
+	}
+	for _, recipe := range recipes {
+		if err := fn(recipe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func assertStatusCode(t *testing.T, got, want int32) {
 	t.Helper()
 	if got != want {
@@ -93,12 +285,13 @@ var (
 	mockRepo = &mockRecipeRepository{}
 	a        = api{
 		recipeRepository: mockRepo,
+		recipeImporter:   importer.NewImporter(mockRepo),
 	}
 )
 
 func TestInsertRecipe(t *testing.T) {
 
-	var p = true
+	var p = false
 	recipeSuccess := entity.RecipeDTO{
 		Title:       "test",
 		Description: "test",
@@ -136,7 +329,7 @@ func TestInsertRecipe(t *testing.T) {
 		assertMessage(t, res.Message, "success insert recipe")
 	})
 
-	t.Run("should return 400 error insert recipe", func(t *testing.T) {
+	t.Run("should return 500 error insert recipe", func(t *testing.T) {
 		body, _ := json.Marshal(recipeFailed)
 
 		req := httptest.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
@@ -144,13 +337,13 @@ func TestInsertRecipe(t *testing.T) {
 
 		a.insertRecipe(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "error insert recipe")
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error insert recipe")
 	})
 
 	t.Run("should return 400 error decode payload", func(t *testing.T) {
@@ -161,16 +354,16 @@ func TestInsertRecipe(t *testing.T) {
 
 		a.insertRecipe(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "error decoding request payload")
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "error decoding request payload")
 	})
 
-	t.Run("should return 400 error validating request payload", func(t *testing.T) {
+	t.Run("should return 422 error validating request payload", func(t *testing.T) {
 		err := recipeFailedValidation.InsertValidate()
 		body, _ := json.Marshal(recipeFailedValidation)
 
@@ -179,13 +372,116 @@ func TestInsertRecipe(t *testing.T) {
 
 		a.insertRecipe(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusUnprocessableEntity)
+		assertMessage(t, res.Detail, err.Error())
+	})
+
+	t.Run("should return 422 with every invalid field", func(t *testing.T) {
+		body := []byte(`{"title": "", "status": "archived", "publish_at": "tomorrow"}`)
+
+		req := httptest.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+		rec := httptest.NewRecorder()
+
+		a.insertRecipe(rec, req)
+
+		if contentType := rec.Header().Get("Content-Type"); contentType != helper.ProblemMediaType {
+			t.Errorf("got Content-Type %q, want %q", contentType, helper.ProblemMediaType)
+		}
+
+		var res struct {
+			helper.Problem
+			Errors []entity.FieldError `json:"errors"`
+		}
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		want := []entity.FieldError{
+			{Field: "title", Message: "title must not be empty"},
+			{Field: "publish_at", Message: "publish_at must be an RFC3339 timestamp"},
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusUnprocessableEntity)
+		assertMessage(t, res.Instance, url)
+		if !reflect.DeepEqual(res.Errors, want) {
+			t.Errorf("got %v, want %v", res.Errors, want)
+		}
+	})
+
+	t.Run("should return 200 when an author creates a published recipe", func(t *testing.T) {
+		for _, body := range []string{`{"title": "test", "status": "published"}`, `{"title": "test", "publish": true}`} {
+			req := withCaller(httptest.NewRequest(http.MethodPost, url, bytes.NewBufferString(body)), 7)
+			rec := httptest.NewRecorder()
+
+			a.insertRecipe(rec, req)
+
+			var res helper.Response
+			if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+				t.Fatalf("error decoding response body, %v", err.Error())
+			}
+
+			assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		}
+	})
+
+	t.Run("should return 401 when an anonymous caller creates a published recipe", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, url, bytes.NewBufferString(`{"title": "test", "publish": true}`))
+		rec := httptest.NewRecorder()
+
+		a.insertRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusUnauthorized)
+		assertMessage(t, res.Detail, "user id is required")
+	})
+
+	t.Run("should return 422 when the title is too long", func(t *testing.T) {
+		body, _ := json.Marshal(entity.RecipeDTO{Title: strings.Repeat("a", entity.MaxTitleLength+1)})
+
+		req := httptest.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+		rec := httptest.NewRecorder()
+
+		a.insertRecipe(rec, req)
+
+		var res struct {
+			helper.Problem
+			Errors []entity.FieldError `json:"errors"`
+		}
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		want := []entity.FieldError{{Field: "title", Message: "title must be at most 100 characters"}}
+		assertStatusCode(t, int32(res.Status), http.StatusUnprocessableEntity)
+		if !reflect.DeepEqual(res.Errors, want) {
+			t.Errorf("got %v, want %v", res.Errors, want)
+		}
+	})
+
+	t.Run("should return 409 on duplicate title", func(t *testing.T) {
+		body, _ := json.Marshal(entity.RecipeDTO{Title: "nasi goreng"})
+
+		req := httptest.NewRequest(http.MethodPost, url, bytes.NewBuffer(body))
+		rec := httptest.NewRecorder()
+
+		a.insertRecipe(rec, req)
+
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, err.Error())
+		assertStatusCode(t, int32(res.Status), http.StatusConflict)
+		assertMessage(t, res.Detail, repository.ErrDuplicateTitle.Error())
 	})
 }
 
@@ -236,10 +532,129 @@ func TestUpdateRecipe(t *testing.T) {
 		assertMessage(t, res.Message, "success update recipe")
 	})
 
-	t.Run("should return 400 error update recipe", func(t *testing.T) {
-		body, _ := json.Marshal(recipeFailed)
+	t.Run("should return etag of the new version on update", func(t *testing.T) {
+		body, _ := json.Marshal(recipeSuccess)
+
+		req := AddChiURLParams(httptest.NewRequest(http.MethodPut, url, bytes.NewBuffer(body)), idSuccess)
+		req.Header.Set("If-Match", `"1"`)
+		rec := httptest.NewRecorder()
+
+		a.updateRecipe(rec, req)
+
+		assertStatusCode(t, int32(rec.Code), http.StatusOK)
+		assertMessage(t, rec.Header().Get("ETag"), `"2"`)
+	})
+
+	t.Run("should return 412 on stale If-Match", func(t *testing.T) {
+		body, _ := json.Marshal(recipeSuccess)
 
 		req := AddChiURLParams(httptest.NewRequest(http.MethodPut, url, bytes.NewBuffer(body)), idSuccess)
+		req.Header.Set("If-Match", `"5"`)
+		rec := httptest.NewRecorder()
+
+		a.updateRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusPreconditionFailed)
+		assertMessage(t, res.Detail, repository.ErrVersionConflict.Error())
+	})
+
+	t.Run("should return 400 on malformed If-Match", func(t *testing.T) {
+		body, _ := json.Marshal(recipeSuccess)
+
+		req := AddChiURLParams(httptest.NewRequest(http.MethodPut, url, bytes.NewBuffer(body)), idSuccess)
+		req.Header.Set("If-Match", `W/"1"`)
+		rec := httptest.NewRecorder()
+
+		a.updateRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "invalid If-Match header")
+	})
+
+	t.Run("should return 200 when status moves along the workflow", func(t *testing.T) {
+		body, _ := json.Marshal(entity.RecipeDTO{Title: "test", Status: entity.StatusArchived})
+
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPut, url, bytes.NewBuffer(body)), idSuccess), 7)
+		rec := httptest.NewRecorder()
+
+		a.updateRecipe(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success update recipe")
+	})
+
+	t.Run("should return 401 when an anonymous caller moves the status", func(t *testing.T) {
+		body, _ := json.Marshal(entity.RecipeDTO{Title: "test", Status: entity.StatusArchived})
+
+		req := AddChiURLParams(httptest.NewRequest(http.MethodPut, url, bytes.NewBuffer(body)), idSuccess)
+		rec := httptest.NewRecorder()
+
+		a.updateRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusUnauthorized)
+		assertMessage(t, res.Detail, "user id is required")
+	})
+
+	t.Run("should return 403 when another author moves the status", func(t *testing.T) {
+		body, _ := json.Marshal(entity.RecipeDTO{Title: "test", Status: entity.StatusArchived})
+
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPut, url, bytes.NewBuffer(body)), idSuccess), 8)
+		rec := httptest.NewRecorder()
+
+		a.updateRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusForbidden)
+		assertMessage(t, res.Detail, "only the author or an editor can move the recipe")
+	})
+
+	t.Run("should return 409 when status move is not allowed", func(t *testing.T) {
+		body, _ := json.Marshal(entity.RecipeDTO{Title: "test", Status: entity.StatusInReview})
+
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPut, url, bytes.NewBuffer(body)), idSuccess), 7)
+		rec := httptest.NewRecorder()
+
+		a.updateRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusConflict)
+		assertMessage(t, res.Detail, "recipe can not move from published to in_review")
+	})
+
+	t.Run("should return 200 when the legacy publish flag publishes a draft", func(t *testing.T) {
+		publish := true
+		body, _ := json.Marshal(entity.RecipeDTO{Title: "test", Publish: &publish})
+
+		req := AddChiURLParams(httptest.NewRequest(http.MethodPut, "/recipe/4", bytes.NewBuffer(body)), map[string]string{"id": "4"})
+		req = req.WithContext(contextWithCaller(req.Context(), entity.Caller{Id: 7, Role: entity.RoleEditor}))
 		rec := httptest.NewRecorder()
 
 		a.updateRecipe(rec, req)
@@ -249,8 +664,42 @@ func TestUpdateRecipe(t *testing.T) {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "error update recipe")
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+	})
+
+	t.Run("should return 409 when an explicit status skips the review", func(t *testing.T) {
+		body, _ := json.Marshal(entity.RecipeDTO{Title: "test", Status: entity.StatusPublished})
+
+		req := AddChiURLParams(httptest.NewRequest(http.MethodPut, "/recipe/4", bytes.NewBuffer(body)), map[string]string{"id": "4"})
+		req = req.WithContext(contextWithCaller(req.Context(), entity.Caller{Id: 7, Role: entity.RoleEditor}))
+		rec := httptest.NewRecorder()
+
+		a.updateRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusConflict)
+		assertMessage(t, res.Detail, "recipe can not move from draft to published")
+	})
+
+	t.Run("should return 500 error update recipe", func(t *testing.T) {
+		body, _ := json.Marshal(recipeFailed)
+
+		req := AddChiURLParams(httptest.NewRequest(http.MethodPut, url, bytes.NewBuffer(body)), idSuccess)
+		rec := httptest.NewRecorder()
+
+		a.updateRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error update recipe")
 	})
 
 	t.Run("should return 400 error decode payload", func(t *testing.T) {
@@ -261,16 +710,16 @@ func TestUpdateRecipe(t *testing.T) {
 
 		a.updateRecipe(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "error decoding request payload")
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "error decoding request payload")
 	})
 
-	t.Run("should return 400 error validating request payload", func(t *testing.T) {
+	t.Run("should return 422 error validating request payload", func(t *testing.T) {
 		err := recipeFailedValidation.InsertValidate()
 		body, _ := json.Marshal(recipeFailedValidation)
 
@@ -279,13 +728,13 @@ func TestUpdateRecipe(t *testing.T) {
 
 		a.updateRecipe(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, err.Error())
+		assertStatusCode(t, int32(res.Status), http.StatusUnprocessableEntity)
+		assertMessage(t, res.Detail, err.Error())
 	})
 
 	t.Run("should return 400 error validating request path id", func(t *testing.T) {
@@ -296,13 +745,13 @@ func TestUpdateRecipe(t *testing.T) {
 
 		a.updateRecipe(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "id must be numeric")
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "id must be numeric")
 	})
 }
 
@@ -337,6 +786,51 @@ func TestGetRecipeById(t *testing.T) {
 		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
 		assertMessage(t, res.Message, "success get detail recipe")
 		assertNotNil(t, res.Data)
+		assertMessage(t, rec.Header().Get("ETag"), `"1"`)
+	})
+
+	t.Run("should return 304 when If-None-Match matches", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), idSuccess)
+		req.Header.Set("If-None-Match", `"1"`)
+		rec := httptest.NewRecorder()
+
+		a.getRecipeById(rec, req)
+
+		assertStatusCode(t, int32(rec.Code), http.StatusNotModified)
+		assertMessage(t, rec.Body.String(), "")
+	})
+
+	t.Run("should return 304 when not modified since", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), idSuccess)
+		req.Header.Set("If-Modified-Since", "Wed, 01 May 2024 10:00:00 GMT")
+		rec := httptest.NewRecorder()
+
+		a.getRecipeById(rec, req)
+
+		assertStatusCode(t, int32(rec.Code), http.StatusNotModified)
+	})
+
+	t.Run("should return 200 when modified since", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), idSuccess)
+		req.Header.Set("If-Modified-Since", "Tue, 30 Apr 2024 10:00:00 GMT")
+		rec := httptest.NewRecorder()
+
+		a.getRecipeById(rec, req)
+
+		assertStatusCode(t, int32(rec.Code), http.StatusOK)
+		assertMessage(t, rec.Header().Get("Last-Modified"), "Wed, 01 May 2024 10:00:00 GMT")
+		assertMessage(t, rec.Header().Get("Cache-Control"), helper.CacheControlRevalidate)
+	})
+
+	t.Run("should keep the response of an identified caller out of shared caches", func(t *testing.T) {
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), idSuccess), 7)
+		rec := httptest.NewRecorder()
+
+		a.getRecipeById(rec, req)
+
+		assertStatusCode(t, int32(rec.Code), http.StatusOK)
+		assertMessage(t, rec.Header().Get("Cache-Control"), helper.CacheControlPrivateRevalidate)
+		assertMessage(t, strings.Join(rec.Header().Values("Vary"), ", "), "Accept, X-User-Id, X-User-Role")
 	})
 
 	t.Run("should return 400 error validating request path id", func(t *testing.T) {
@@ -345,43 +839,74 @@ func TestGetRecipeById(t *testing.T) {
 
 		a.getRecipeById(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "id must be numeric")
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "id must be numeric")
 	})
 
-	t.Run("should return 400 error recipe not found", func(t *testing.T) {
+	t.Run("should return 404 error recipe not found", func(t *testing.T) {
 		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), idNotFound)
 		rec := httptest.NewRecorder()
 
 		a.getRecipeById(rec, req)
 
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "recipe not found")
+	})
+
+	t.Run("should return 404 recipe not found while unpublished", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "4"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeById(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "recipe not found")
+	})
+
+	t.Run("should return 200 unpublished recipe for editor", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "4"})
+		req = req.WithContext(contextWithCaller(req.Context(), entity.Caller{Id: 7, Role: entity.RoleEditor}))
+		rec := httptest.NewRecorder()
+
+		a.getRecipeById(rec, req)
+
 		var res helper.Response
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "recipe not found")
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success get detail recipe")
 	})
 
-	t.Run("should return 400 error getting recipe", func(t *testing.T) {
+	t.Run("should return 500 error getting recipe", func(t *testing.T) {
 		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), idError)
 		rec := httptest.NewRecorder()
 
 		a.getRecipeById(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "error getting recipe")
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error getting recipe")
 	})
 }
 
@@ -420,28 +945,59 @@ func TestDeleteRecipeById(t *testing.T) {
 
 		a.deleteRecipeById(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "id must be numeric")
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "id must be numeric")
 	})
 
-	t.Run("should return 400 error deleting recipe", func(t *testing.T) {
+	t.Run("should return 412 on stale If-Match", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodDelete, url, nil), idSuccess)
+		req.Header.Set("If-Match", `"5"`)
+		rec := httptest.NewRecorder()
+
+		a.deleteRecipeById(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusPreconditionFailed)
+		assertMessage(t, res.Detail, repository.ErrVersionConflict.Error())
+	})
+
+	t.Run("should return 404 error recipe not found", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodDelete, url, nil), map[string]string{"id": "0"})
+		rec := httptest.NewRecorder()
+
+		a.deleteRecipeById(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "recipe not found")
+	})
+
+	t.Run("should return 500 error deleting recipe", func(t *testing.T) {
 		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), idError)
 		rec := httptest.NewRecorder()
 
 		a.deleteRecipeById(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "error delete recipe")
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error delete recipe")
 	})
 }
 
@@ -479,19 +1035,52 @@ func TestGetListRecipe(t *testing.T) {
 		assertRecipesEqual(t, got, want)
 	})
 
+	t.Run("should return 304 when the page etag matches", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, urlSuccess, nil)
+		rec := httptest.NewRecorder()
+
+		a.getListRecipe(rec, req)
+
+		etag := rec.Header().Get("ETag")
+		if etag == "" {
+			t.Fatalf("got empty ETag")
+		}
+
+		req = httptest.NewRequest(http.MethodGet, urlSuccess, nil)
+		req.Header.Set("If-None-Match", etag)
+		rec = httptest.NewRecorder()
+
+		a.getListRecipe(rec, req)
+
+		assertStatusCode(t, int32(rec.Code), http.StatusNotModified)
+	})
+
+	t.Run("should ignore If-Modified-Since on the page", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, urlSuccess, nil)
+		req.Header.Set("If-Modified-Since", "Fri, 01 Jan 2100 00:00:00 GMT")
+		rec := httptest.NewRecorder()
+
+		a.getListRecipe(rec, req)
+
+		assertStatusCode(t, int32(rec.Code), http.StatusOK)
+		if got := rec.Header().Get("Last-Modified"); got != "" {
+			t.Errorf("got Last-Modified %s, want none", got)
+		}
+	})
+
 	t.Run("should return 400 error validating query param page", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, urlPageInvalid, nil)
 		rec := httptest.NewRecorder()
 
 		a.getListRecipe(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "page must be numeric")
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "page must be numeric")
 	})
 
 	t.Run("should return 400 error validating query param limit", func(t *testing.T) {
@@ -500,28 +1089,43 @@ func TestGetListRecipe(t *testing.T) {
 
 		a.getListRecipe(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "limit must be numeric")
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "limit must be numeric")
 	})
 
-	t.Run("should return 400 error get list recipe", func(t *testing.T) {
+	t.Run("should return 500 error get list recipe", func(t *testing.T) {
 		req := httptest.NewRequest(http.MethodGet, urlFailed, nil)
 		rec := httptest.NewRecorder()
 
 		a.getListRecipe(rec, req)
 
-		var res helper.Response
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error get list recipe")
+	})
+
+	t.Run("should return 503 when the query times out", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/recipe-list?limit=99", nil)
+		rec := httptest.NewRecorder()
+
+		a.getListRecipe(rec, req)
+
+		var res helper.Problem
 		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
 			t.Fatalf("error decoding response body, %v", err.Error())
 		}
 
-		assertStatusCode(t, int32(res.StatusCode), http.StatusBadRequest)
-		assertMessage(t, res.Message, "error get list recipe")
+		assertStatusCode(t, int32(res.Status), http.StatusServiceUnavailable)
+		assertMessage(t, res.Detail, "error get list recipe")
 	})
 }
 
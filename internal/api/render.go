@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+	"github.com/rhnauf/recipe-api/internal/logging"
+)
+
+// writeRecipePage answers with a rendered page of the recipe, variant tells the representations apart in the ETag
+func writeRecipePage(w http.ResponseWriter, r *http.Request, recipe *entity.Recipe, variant, mediaType string, renderPage func(*entity.Recipe) ([]byte, error)) {
+	etag := helper.VariantETag(recipe.Version, variant)
+	setCacheHeaders(w, r, etag, recipe.UpdatedAt)
+	if helper.IsNotModified(r, etag, recipe.UpdatedAt) {
+		helper.HandleNotModified(w)
+		return
+	}
+
+	page, err := renderPage(recipe)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("error render recipe page", "error", err, "media_type", mediaType)
+		helper.HandleInternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(page)
+}
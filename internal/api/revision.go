@@ -0,0 +1,151 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func (a *api) getRecipeRevisions(w http.ResponseWriter, r *http.Request) {
+	pathParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(pathParam, 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
+		return
+	}
+
+	revisions, err := a.recipeRepository.GetRecipeRevisions(r.Context(), viewer(r), id)
+	if err != nil {
+		writeError(w, r, err, "error get list revision")
+		return
+	}
+
+	res := make([]*entity.RevisionDTO, len(revisions))
+	for idx, revision := range revisions {
+		res[idx] = revision.ToDTO()
+	}
+
+	helper.HandleResponse(w, http.StatusOK, "success get list revision", res)
+}
+
+func (a *api) getRecipeRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
+		return
+	}
+
+	rev, err := strconv.ParseInt(chi.URLParam(r, "rev"), 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "revision must be numeric", nil)
+		return
+	}
+
+	revision, err := a.recipeRepository.GetRecipeRevision(r.Context(), viewer(r), id, rev)
+	if err != nil {
+		writeError(w, r, notFound(err, "revision not found"), "error getting revision")
+		return
+	}
+
+	helper.HandleResponse(w, http.StatusOK, "success get detail revision", revision.ToDTO())
+}
+
+func (a *api) getRecipeRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
+		return
+	}
+
+	from, err := strconv.ParseInt(r.URL.Query().Get("from"), 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "from must be numeric", nil)
+		return
+	}
+
+	to, err := strconv.ParseInt(r.URL.Query().Get("to"), 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "to must be numeric", nil)
+		return
+	}
+
+	revisions := make([]*entity.Revision, 2)
+	for idx, rev := range []int64{from, to} {
+		revisions[idx], err = a.recipeRepository.GetRecipeRevision(r.Context(), viewer(r), id, rev)
+		if err != nil {
+			writeError(w, r, notFound(err, "revision not found"), "error getting revision")
+			return
+		}
+	}
+
+	res := entity.RevisionDiffDTO{
+		From:    from,
+		To:      to,
+		Changes: []*entity.FieldDiffDTO{},
+	}
+
+	fields := []struct {
+		name     string
+		from, to string
+	}{
+		{"title", revisions[0].Title, revisions[1].Title},
+		{"description", revisions[0].Description, revisions[1].Description},
+		{"instruction", revisions[0].Instruction, revisions[1].Instruction},
+	}
+	for _, field := range fields {
+		if field.from == field.to {
+			continue
+		}
+
+		diff := helper.DiffLines(field.from, field.to)
+		lines := make([]*entity.DiffLineDTO, len(diff))
+		for idx, line := range diff {
+			lines[idx] = &entity.DiffLineDTO{Op: line.Op, Text: line.Text}
+		}
+		res.Changes = append(res.Changes, &entity.FieldDiffDTO{Field: field.name, Lines: lines})
+	}
+
+	helper.HandleResponse(w, http.StatusOK, "success get revision diff", res)
+}
+
+func (a *api) restoreRecipeRevision(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
+		return
+	}
+
+	rev, err := strconv.ParseInt(chi.URLParam(r, "rev"), 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "revision must be numeric", nil)
+		return
+	}
+
+	caller, ok := callerFromContext(r.Context())
+	if !ok {
+		helper.HandleProblem(w, r, http.StatusUnauthorized, "user id is required", nil)
+		return
+	}
+
+	recipe, err := a.recipeRepository.GetRecipeById(r.Context(), caller, id)
+	if err != nil {
+		writeError(w, r, notFound(err, "recipe not found"), "error restore revision")
+		return
+	}
+	if !caller.CanManage(recipe.OwnerId) {
+		helper.HandleProblem(w, r, http.StatusForbidden, "only the author or an editor can restore the recipe", nil)
+		return
+	}
+
+	newRevision, err := a.recipeRepository.RestoreRecipeRevision(r.Context(), caller, id, rev)
+	if err != nil {
+		writeError(w, r, notFound(err, "revision not found"), "error restore revision")
+		return
+	}
+
+	helper.HandleResponse(w, http.StatusOK, "success restore revision", entity.RevisionDTO{Revision: newRevision})
+}
@@ -0,0 +1,249 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func TestGetRecipeRevisions(t *testing.T) {
+
+	url := "/recipe/1/revisions"
+
+	t.Run("should return 200 success get list revision", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "1"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeRevisions(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success get list revision")
+		assertNotNil(t, res.Data)
+	})
+
+	t.Run("should return 500 error get list revision", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "2"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeRevisions(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error get list revision")
+	})
+}
+
+func TestGetRecipeRevision(t *testing.T) {
+
+	url := "/recipe/1/revisions/1"
+
+	t.Run("should return 200 success get detail revision", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "1", "rev": "1"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeRevision(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success get detail revision")
+	})
+
+	t.Run("should return 400 error validating revision", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "1", "rev": "asdf"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeRevision(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "revision must be numeric")
+	})
+
+	t.Run("should return 404 error revision not found", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "1", "rev": "9"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeRevision(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "revision not found")
+	})
+}
+
+func TestGetRecipeRevisionDiff(t *testing.T) {
+
+	t.Run("should return 200 with line diff of changed fields", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, "/recipe/1/revisions/diff?from=1&to=2", nil), map[string]string{"id": "1"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeRevisionDiff(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		byteData, _ := json.Marshal(res.Data)
+
+		var got entity.RevisionDiffDTO
+		_ = json.Unmarshal(byteData, &got)
+
+		want := entity.RevisionDiffDTO{
+			From: 1,
+			To:   2,
+			Changes: []*entity.FieldDiffDTO{
+				{
+					Field: "instruction",
+					Lines: []*entity.DiffLineDTO{
+						{Op: helper.DiffEqual, Text: "cook rice"},
+						{Op: helper.DiffDelete, Text: "add egg"},
+						{Op: helper.DiffInsert, Text: "add chicken"},
+					},
+				},
+			},
+		}
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success get revision diff")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("should return 400 error validating query param from", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, "/recipe/1/revisions/diff?to=2", nil), map[string]string{"id": "1"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeRevisionDiff(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusBadRequest)
+		assertMessage(t, res.Detail, "from must be numeric")
+	})
+
+	t.Run("should return 404 error revision not found", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, "/recipe/1/revisions/diff?from=1&to=9", nil), map[string]string{"id": "1"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeRevisionDiff(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "revision not found")
+	})
+}
+
+func TestRestoreRecipeRevision(t *testing.T) {
+
+	url := "/recipe/1/revisions/1/restore"
+
+	t.Run("should return 200 success restore revision", func(t *testing.T) {
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, nil), map[string]string{"id": "1", "rev": "1"}), 7)
+		rec := httptest.NewRecorder()
+
+		a.restoreRecipeRevision(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success restore revision")
+	})
+
+	t.Run("should return 404 error revision not found", func(t *testing.T) {
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, nil), map[string]string{"id": "1", "rev": "9"}), 7)
+		rec := httptest.NewRecorder()
+
+		a.restoreRecipeRevision(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "revision not found")
+	})
+
+	t.Run("should return 500 error restore revision", func(t *testing.T) {
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, nil), map[string]string{"id": "2", "rev": "1"}), 7)
+		rec := httptest.NewRecorder()
+
+		a.restoreRecipeRevision(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error restore revision")
+	})
+
+	t.Run("should return 401 when an anonymous caller restores", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodPost, url, nil), map[string]string{"id": "1", "rev": "1"})
+		rec := httptest.NewRecorder()
+
+		a.restoreRecipeRevision(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusUnauthorized)
+		assertMessage(t, res.Detail, "user id is required")
+	})
+
+	t.Run("should return 403 when another author restores", func(t *testing.T) {
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, nil), map[string]string{"id": "1", "rev": "1"}), 8)
+		rec := httptest.NewRecorder()
+
+		a.restoreRecipeRevision(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusForbidden)
+		assertMessage(t, res.Detail, "only the author or an editor can restore the recipe")
+	})
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// an old slug of a renamed recipe answers with a permanent redirect to the current one
+func (a *api) getRecipeBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	recipe, err := a.recipeRepository.GetRecipeBySlug(r.Context(), viewer(r), slug)
+	if err == nil {
+		writeRecipe(w, r, recipe)
+		return
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		writeError(w, r, err, "error getting recipe")
+		return
+	}
+
+	current, err := a.recipeRepository.GetRecipeSlugRedirect(r.Context(), viewer(r), slug)
+	if err != nil {
+		writeError(w, r, notFound(err, "recipe not found"), "error getting recipe")
+		return
+	}
+
+	http.Redirect(w, r, "/recipe/slug/"+url.PathEscape(current), http.StatusMovedPermanently)
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func TestGetRecipeBySlug(t *testing.T) {
+
+	url := "/recipe/slug/{slug}"
+
+	t.Run("should return 200 success get detail recipe", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"slug": "nasi-goreng"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeBySlug(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		byteData, _ := json.Marshal(res.Data)
+
+		var got entity.RecipeDTO
+		_ = json.Unmarshal(byteData, &got)
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success get detail recipe")
+		if got.Id != 1 || got.Slug != "nasi-goreng" {
+			t.Errorf("got %v, want recipe 1 with slug nasi-goreng", got)
+		}
+		if etag := rec.Header().Get("ETag"); etag != `"1"` {
+			t.Errorf("got ETag %q, want %q", etag, `"1"`)
+		}
+	})
+
+	t.Run("should return 301 to the current slug for an old slug", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"slug": "nasi-goreng-biasa"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeBySlug(rec, req)
+
+		if rec.Code != http.StatusMovedPermanently {
+			t.Errorf("got %d, want %d", rec.Code, http.StatusMovedPermanently)
+		}
+		if location := rec.Header().Get("Location"); location != "/recipe/slug/nasi-goreng" {
+			t.Errorf("got Location %q, want %q", location, "/recipe/slug/nasi-goreng")
+		}
+	})
+
+	t.Run("should return 404 recipe not found", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"slug": "rendang"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeBySlug(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "recipe not found")
+	})
+
+	t.Run("should return 500 error getting recipe", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"slug": "error"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeBySlug(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error getting recipe")
+	})
+}
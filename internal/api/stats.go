@@ -0,0 +1,17 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+/*
+	GET /stats/db shows how busy the connection pool is, a growing wait_count means
+	requests queue for a connection and DB_MAX_OPEN_CONNS may be too low
+*/
+
+func (a *api) getDBStats(w http.ResponseWriter, r *http.Request) {
+	helper.HandleResponse(w, http.StatusOK, "success get db stats", entity.NewDBStatsDTO(a.dbStats()))
+}
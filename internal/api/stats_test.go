@@ -0,0 +1,38 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func TestGetDBStats(t *testing.T) {
+	t.Run("should return 200 with the pool stats", func(t *testing.T) {
+		a := api{dbStats: func() sql.DBStats {
+			return sql.DBStats{MaxOpenConnections: 25, OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 4, WaitDuration: 1500 * time.Millisecond}
+		}}
+
+		req := httptest.NewRequest(http.MethodGet, "/stats/db", nil)
+		rec := httptest.NewRecorder()
+
+		a.getDBStats(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success get db stats")
+
+		stats, _ := res.Data.(map[string]interface{})
+		if stats["open_connections"] != float64(3) || stats["wait_duration_ms"] != float64(1500) {
+			t.Errorf("got %v, want 3 open connections and 1500ms of wait", res.Data)
+		}
+	})
+}
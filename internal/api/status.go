@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+// only the author or the editorial team moves a recipe, every move is audited with who made it
+func (a *api) transitionRecipeStatus(w http.ResponseWriter, r *http.Request) {
+	caller, ok := callerFromContext(r.Context())
+	if !ok {
+		helper.HandleProblem(w, r, http.StatusUnauthorized, "user id is required", nil)
+		return
+	}
+
+	var requestTransition entity.StatusTransitionDTO
+	if err := json.NewDecoder(r.Body).Decode(&requestTransition); err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "error decoding request payload", nil)
+		return
+	}
+
+	pathParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(pathParam, 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
+		return
+	}
+
+	if err := requestTransition.Validate(); err != nil {
+		writeError(w, r, err, "error transition recipe status")
+		return
+	}
+
+	recipe, err := a.recipeRepository.GetRecipeById(r.Context(), viewer(r), id)
+	if err != nil {
+		writeError(w, r, notFound(err, "recipe not found"), "error getting recipe")
+		return
+	}
+	if !caller.CanManage(recipe.OwnerId) {
+		helper.HandleProblem(w, r, http.StatusForbidden, "only the author or an editor can move the recipe", nil)
+		return
+	}
+
+	if !entity.CanTransition(recipe.Status, requestTransition.ToStatus) {
+		helper.HandleProblem(w, r, http.StatusConflict, entity.TransitionNotAllowed(recipe.Status, requestTransition.ToStatus).Error(), nil)
+		return
+	}
+
+	version, err := a.recipeRepository.TransitionRecipeStatus(r.Context(), id, recipe.Status, requestTransition.ToStatus, &caller.Id)
+	if err != nil {
+		writeError(w, r, err, "error transition recipe status")
+		return
+	}
+
+	res := entity.StatusTransitionDTO{
+		FromStatus: recipe.Status,
+		ToStatus:   requestTransition.ToStatus,
+		ChangedBy:  &caller.Id,
+		Version:    version,
+	}
+
+	w.Header().Set("ETag", helper.ETag(version))
+	helper.HandleResponse(w, http.StatusOK, "success transition recipe status", res)
+}
+
+func (a *api) getRecipeStatusTransitions(w http.ResponseWriter, r *http.Request) {
+	pathParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(pathParam, 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
+		return
+	}
+
+	transitions, err := a.recipeRepository.GetRecipeStatusTransitions(r.Context(), viewer(r), id)
+	if err != nil {
+		writeError(w, r, err, "error get list status transition")
+		return
+	}
+
+	res := make([]*entity.StatusTransitionDTO, len(transitions))
+	for idx, transition := range transitions {
+		res[idx] = transition.ToDTO()
+	}
+
+	helper.HandleResponse(w, http.StatusOK, "success get list status transition", res)
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func TestTransitionRecipeStatus(t *testing.T) {
+
+	url := "/recipe/1/transitions"
+
+	idSuccess := map[string]string{
+		"id": "1",
+	}
+	idNotFound := map[string]string{
+		"id": "0",
+	}
+
+	t.Run("should return 200 success transition recipe status", func(t *testing.T) {
+		body, _ := json.Marshal(entity.StatusTransitionDTO{ToStatus: entity.StatusArchived})
+
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, bytes.NewBuffer(body)), idSuccess), 7)
+		rec := httptest.NewRecorder()
+
+		a.transitionRecipeStatus(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success transition recipe status")
+		assertMessage(t, rec.Header().Get("ETag"), `"2"`)
+	})
+
+	t.Run("should return 401 error user id is required", func(t *testing.T) {
+		body, _ := json.Marshal(entity.StatusTransitionDTO{ToStatus: entity.StatusArchived})
+
+		req := AddChiURLParams(httptest.NewRequest(http.MethodPost, url, bytes.NewBuffer(body)), idSuccess)
+		rec := httptest.NewRecorder()
+
+		a.transitionRecipeStatus(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusUnauthorized)
+		assertMessage(t, res.Detail, "user id is required")
+	})
+
+	t.Run("should return 403 when another author moves the recipe", func(t *testing.T) {
+		body, _ := json.Marshal(entity.StatusTransitionDTO{ToStatus: entity.StatusArchived})
+
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, bytes.NewBuffer(body)), idSuccess), 8)
+		rec := httptest.NewRecorder()
+
+		a.transitionRecipeStatus(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusForbidden)
+		assertMessage(t, res.Detail, "only the author or an editor can move the recipe")
+	})
+
+	t.Run("should return 409 when transition is not allowed", func(t *testing.T) {
+		body, _ := json.Marshal(entity.StatusTransitionDTO{ToStatus: entity.StatusInReview})
+
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, bytes.NewBuffer(body)), idSuccess), 7)
+		rec := httptest.NewRecorder()
+
+		a.transitionRecipeStatus(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusConflict)
+		assertMessage(t, res.Detail, "recipe can not move from published to in_review")
+	})
+
+	t.Run("should return 422 error validating status", func(t *testing.T) {
+		requestTransition := entity.StatusTransitionDTO{ToStatus: "deleted"}
+		err := requestTransition.Validate()
+		body, _ := json.Marshal(requestTransition)
+
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, bytes.NewBuffer(body)), idSuccess), 7)
+		rec := httptest.NewRecorder()
+
+		a.transitionRecipeStatus(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusUnprocessableEntity)
+		assertMessage(t, res.Detail, err.Error())
+	})
+
+	t.Run("should return 404 error recipe not found", func(t *testing.T) {
+		body, _ := json.Marshal(entity.StatusTransitionDTO{ToStatus: entity.StatusArchived})
+
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, bytes.NewBuffer(body)), idNotFound), 7)
+		rec := httptest.NewRecorder()
+
+		a.transitionRecipeStatus(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "recipe not found")
+	})
+}
+
+func TestGetRecipeStatusTransitions(t *testing.T) {
+
+	url := "/recipe/1/transitions"
+
+	t.Run("should return 200 success get list status transition", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "1"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeStatusTransitions(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success get list status transition")
+		assertNotNil(t, res.Data)
+	})
+
+	t.Run("should return 500 error get list status transition", func(t *testing.T) {
+		req := AddChiURLParams(httptest.NewRequest(http.MethodGet, url, nil), map[string]string{"id": "2"})
+		rec := httptest.NewRecorder()
+
+		a.getRecipeStatusTransitions(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error get list status transition")
+	})
+}
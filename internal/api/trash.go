@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+// trashOwner scopes the trash to the caller, admins get a nil owner to see every trashed recipe
+func trashOwner(caller entity.Caller) *int64 {
+	if caller.IsAdmin() {
+		return nil
+	}
+	return &caller.Id
+}
+
+func (a *api) getListDeletedRecipe(w http.ResponseWriter, r *http.Request) {
+	caller, ok := callerFromContext(r.Context())
+	if !ok {
+		helper.HandleProblem(w, r, http.StatusUnauthorized, "user id is required", nil)
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	recipes, err := a.recipeRepository.GetListDeletedRecipe(r.Context(), trashOwner(caller), limit, offset)
+	if err != nil {
+		writeError(w, r, err, "error get list trash")
+		return
+	}
+
+	res := make([]*entity.RecipeDTO, len(recipes))
+	for idx, recipe := range recipes {
+		res[idx] = &entity.RecipeDTO{
+			Id:        recipe.Id,
+			Title:     recipe.Title,
+			OwnerId:   recipe.OwnerId,
+			DeletedAt: recipe.DeletedAt.Format(time.RFC3339),
+		}
+	}
+
+	helper.HandleResponse(w, http.StatusOK, "success get list trash", res)
+}
+
+func (a *api) restoreDeletedRecipe(w http.ResponseWriter, r *http.Request) {
+	caller, ok := callerFromContext(r.Context())
+	if !ok {
+		helper.HandleProblem(w, r, http.StatusUnauthorized, "user id is required", nil)
+		return
+	}
+
+	pathParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(pathParam, 0, 64)
+	if err != nil {
+		helper.HandleProblem(w, r, http.StatusBadRequest, "id must be numeric", nil)
+		return
+	}
+
+	err = a.recipeRepository.RestoreDeletedRecipe(r.Context(), id, trashOwner(caller))
+	if err != nil {
+		writeError(w, r, notFound(err, "recipe not found in trash"), "error restore recipe")
+		return
+	}
+
+	helper.HandleResponse(w, http.StatusOK, "success restore recipe", nil)
+}
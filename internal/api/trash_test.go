@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func TestGetListDeletedRecipe(t *testing.T) {
+
+	url := "/trash?page=1&limit=10"
+
+	t.Run("should return 200 success get list trash of the owner", func(t *testing.T) {
+		req := withCaller(httptest.NewRequest(http.MethodGet, url, nil), 7)
+		rec := httptest.NewRecorder()
+
+		a.getListDeletedRecipe(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		byteData, _ := json.Marshal(res.Data)
+
+		var got []*entity.RecipeDTO
+		_ = json.Unmarshal(byteData, &got)
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success get list trash")
+		if len(got) != 1 || *got[0].OwnerId != 7 || got[0].DeletedAt != "2024-05-01T10:00:00Z" {
+			t.Errorf("got %v, want the trashed recipe of owner 7", got)
+		}
+	})
+
+	t.Run("should return 401 when caller is anonymous", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		rec := httptest.NewRecorder()
+
+		a.getListDeletedRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusUnauthorized)
+		assertMessage(t, res.Detail, "user id is required")
+	})
+
+	t.Run("should list the whole trash for admins", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req = req.WithContext(contextWithCaller(req.Context(), entity.Caller{Id: 1, Role: entity.RoleAdmin}))
+		rec := httptest.NewRecorder()
+
+		a.getListDeletedRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		// the mock fails on an unscoped listing, which proves the owner filter was dropped
+		assertStatusCode(t, int32(res.Status), http.StatusInternalServerError)
+		assertMessage(t, res.Detail, "error get list trash")
+	})
+}
+
+func TestRestoreDeletedRecipe(t *testing.T) {
+
+	url := "/recipe/1/restore"
+
+	t.Run("should return 200 success restore recipe", func(t *testing.T) {
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, nil), map[string]string{"id": "1"}), 7)
+		rec := httptest.NewRecorder()
+
+		a.restoreDeletedRecipe(rec, req)
+
+		var res helper.Response
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.StatusCode), http.StatusOK)
+		assertMessage(t, res.Message, "success restore recipe")
+	})
+
+	t.Run("should return 404 error recipe not found in trash", func(t *testing.T) {
+		req := withCaller(AddChiURLParams(httptest.NewRequest(http.MethodPost, url, nil), map[string]string{"id": "0"}), 7)
+		rec := httptest.NewRecorder()
+
+		a.restoreDeletedRecipe(rec, req)
+
+		var res helper.Problem
+		if err := json.NewDecoder(rec.Result().Body).Decode(&res); err != nil {
+			t.Fatalf("error decoding response body, %v", err.Error())
+		}
+
+		assertStatusCode(t, int32(res.Status), http.StatusNotFound)
+		assertMessage(t, res.Detail, "recipe not found in trash")
+	})
+}
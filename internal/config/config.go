@@ -0,0 +1,229 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/rhnauf/recipe-api/internal/ratelimit"
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+// Config is the typed configuration shared by every command of the binary
+type Config struct {
+	App       App
+	DB        DB
+	Jobs      Jobs
+	Health    Health
+	Tracing   Tracing
+	Logging   Logging
+	RateLimit RateLimit
+}
+
+type App struct {
+	Port int
+
+	// on shutdown the app reports not ready for ShutdownDrain, then waits up to ShutdownTimeout for the running requests
+	ShutdownDrain   time.Duration
+	ShutdownTimeout time.Duration
+}
+
+type DB struct {
+	Host     string
+	Port     int
+	Username string
+	Name     string
+	Password string
+	SSLMode  string
+	Timeouts repository.Timeouts
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// the first ping is retried with a backoff growing from ConnectBackoff up to ConnectBackoffMax until ConnectTimeout
+	ConnectTimeout    time.Duration
+	ConnectBackoff    time.Duration
+	ConnectBackoffMax time.Duration
+}
+
+type Jobs struct {
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+	PublishInterval    time.Duration
+}
+
+/*
+Tracing picks where the spans go, none keeps the tracer a no-op, stdout prints them for local testing
+and otlp sends them over http to OTLPEndpoint, or to OTEL_EXPORTER_OTLP_ENDPOINT when it is empty
+*/
+type Tracing struct {
+	Exporter     string
+	OTLPEndpoint string
+	SampleRatio  float64
+}
+
+type Logging struct {
+	Format string
+	Level  slog.Level
+}
+
+/*
+RateLimit holds the default policy of every client and the stricter ones of single routes, keyed by e.g. POST /recipe,
+APIKeyHashes are the sha256 hex digests of the issued api keys, only those count as a client of their own
+*/
+type RateLimit struct {
+	Enabled      bool
+	Default      ratelimit.Policy
+	Routes       map[string]ratelimit.Policy
+	APIKeyHashes []string
+}
+
+// Health configures the checks of /readyz, the blob storage is only checked when its url is set
+type Health struct {
+	CheckTimeout   time.Duration
+	BlobStorageURL string
+}
+
+// DataSourceName is the connection string of lib/pq, the values are quoted so a password may hold spaces or quotes
+func (d DB) DataSourceName() string {
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return fmt.Sprintf("host='%s' port=%d user='%s' dbname='%s' password='%s' sslmode='%s'",
+		quote.Replace(d.Host), d.Port, quote.Replace(d.Username), quote.Replace(d.Name), quote.Replace(d.Password), quote.Replace(d.SSLMode))
+}
+
+// Error reports every missing or invalid setting at once instead of stopping at the first one
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) add(format string, args ...interface{}) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+func (e *Error) err() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+/*
+Load reads the settings from, in increasing precedence, the defaults, the yaml or toml file given by -config
+or CONFIG_FILE, the dotenv file given by -env-file, the environment and the flags, e.g. -db-host for the db.host
+key of the file and DB_HOST of the environment. The flags stop at the first argument that is not one, the
+remaining arguments are returned for the command. A missing dotenv file is fine, a missing config file is not
+*/
+func Load(args []string, lookupEnv func(key string) (string, bool)) (*Config, []string, error) {
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	configFile := flags.String("config", "", "yaml or toml file with the settings, CONFIG_FILE when empty")
+	envFile := flags.String("env-file", ".env", "dotenv file read when it exists")
+	for _, s := range settings {
+		usage := fmt.Sprintf("%s, %s", s.usage, s.env)
+		if s.fallback != "" {
+			usage += fmt.Sprintf(" (default %q)", s.fallback)
+		}
+		flags.String(s.flag(), "", usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	problems := &Error{}
+	values := make(map[string]string)
+	sources := make(map[string]string)
+	set := func(key, value, source string) {
+		values[key] = value
+		sources[key] = source
+	}
+
+	for _, s := range settings {
+		set(s.env, s.fallback, "the default")
+	}
+
+	dotenv, err := godotenv.Read(*envFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		problems.add("error reading %s => %v", *envFile, err)
+	}
+
+	path := *configFile
+	if path == "" {
+		path, _ = lookupEnv("CONFIG_FILE")
+	}
+	if path == "" {
+		path = dotenv["CONFIG_FILE"]
+	}
+	if path != "" {
+		fileValues, err := readFile(path)
+		if err != nil {
+			problems.add("error reading %s => %v", path, err)
+		}
+		keys := make([]string, 0, len(fileValues))
+		for key := range fileValues {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s, ok := settingByPath(key)
+			if !ok {
+				problems.add("unknown key %s in %s", key, path)
+				continue
+			}
+			set(s.env, fileValues[key], path)
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := dotenv[s.env]; ok {
+			set(s.env, value, *envFile)
+		}
+		if value, ok := lookupEnv(s.env); ok {
+			set(s.env, value, "the environment")
+		}
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag() == f.Name {
+				set(s.env, f.Value.String(), "-"+f.Name)
+			}
+		}
+	})
+
+	cfg := &Config{DB: DB{Timeouts: repository.DefaultTimeouts()}}
+	for _, s := range settings {
+		value := values[s.env]
+		if value == "" && s.required {
+			problems.add("%s is missing, set it in the environment, the dotenv file, as %s of the config file or with -%s", s.env, s.path, s.flag())
+			continue
+		}
+		if err := s.apply(cfg, value); err != nil {
+			problems.add("%s=%q from %s is invalid => %v", s.env, value, sources[s.env], err)
+		}
+	}
+
+	if cfg.DB.MaxOpenConns > 0 && cfg.DB.MaxIdleConns > cfg.DB.MaxOpenConns {
+		problems.add("DB_MAX_IDLE_CONNS=%d is more than DB_MAX_OPEN_CONNS=%d", cfg.DB.MaxIdleConns, cfg.DB.MaxOpenConns)
+	}
+	if cfg.DB.ConnectBackoff > cfg.DB.ConnectBackoffMax {
+		problems.add("DB_CONNECT_BACKOFF=%v is more than DB_CONNECT_BACKOFF_MAX=%v", cfg.DB.ConnectBackoff, cfg.DB.ConnectBackoffMax)
+	}
+
+	if err := problems.err(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, flags.Args(), nil
+}
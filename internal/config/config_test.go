@@ -0,0 +1,186 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envOf(env map[string]string) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("error writing %s, %v", name, err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	required := map[string]string{"DB_USERNAME": "root", "DB_NAME": "recipedb"}
+
+	t.Run("should fall back to the defaults", func(t *testing.T) {
+		cfg, rest, err := Load([]string{"-env-file", "missing.env", "serve"}, envOf(required))
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		if cfg.App.Port != 3000 || cfg.DB.Host != "localhost" || cfg.DB.Port != 5432 || cfg.DB.SSLMode != "require" {
+			t.Errorf("got %+v, want the defaults", cfg)
+		}
+		if cfg.DB.Timeouts.Default != 5*time.Second || cfg.DB.Timeouts.Operations["ExportRecipes"] != 2*time.Minute {
+			t.Errorf("got %+v, want the default timeouts", cfg.DB.Timeouts)
+		}
+		if cfg.Jobs.TrashRetention != 720*time.Hour {
+			t.Errorf("got %v, want %v", cfg.Jobs.TrashRetention, 720*time.Hour)
+		}
+		if len(rest) != 1 || rest[0] != "serve" {
+			t.Errorf("got %v, want [serve]", rest)
+		}
+	})
+
+	t.Run("should let flags override the environment, the dotenv file and the config file", func(t *testing.T) {
+		file := writeFile(t, "config.yaml", `
+app:
+  port: 4000
+db:
+  host: file-host
+  port: 6000
+  name: filedb
+  username: file-user
+  operation_timeouts:
+    ExportRecipes: 10m
+jobs:
+  trash_retention: 48h
+`)
+		envFile := writeFile(t, ".env", "DB_HOST=dotenv-host\nDB_PORT=6001\nDB_NAME=dotenvdb\n")
+		env := map[string]string{"DB_HOST": "env-host", "DB_PORT": "6002"}
+
+		cfg, _, err := Load([]string{"-config", file, "-env-file", envFile, "-db-host", "flag-host"}, envOf(env))
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		if cfg.DB.Host != "flag-host" {
+			t.Errorf("got host %s, want flag-host", cfg.DB.Host)
+		}
+		if cfg.DB.Port != 6002 {
+			t.Errorf("got port %d, want 6002", cfg.DB.Port)
+		}
+		if cfg.DB.Name != "dotenvdb" {
+			t.Errorf("got name %s, want dotenvdb", cfg.DB.Name)
+		}
+		if cfg.DB.Username != "file-user" || cfg.App.Port != 4000 || cfg.Jobs.TrashRetention != 48*time.Hour {
+			t.Errorf("got %+v, want the values of the config file", cfg)
+		}
+		if cfg.DB.Timeouts.Operations["ExportRecipes"] != 10*time.Minute || cfg.DB.Timeouts.Operations["PurgeDeletedRecipes"] != time.Minute {
+			t.Errorf("got %v, want ExportRecipes overridden and the other defaults kept", cfg.DB.Timeouts.Operations)
+		}
+	})
+
+	t.Run("should read a toml config file", func(t *testing.T) {
+		file := writeFile(t, "config.toml", `
+[db]
+host = "toml-host"
+port = 6000
+query_timeout = "2s"
+
+[db.operation_timeouts]
+GetListRecipe = "1s"
+`)
+
+		cfg, _, err := Load([]string{"-config", file, "-env-file", "missing.env"}, envOf(required))
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		if cfg.DB.Host != "toml-host" || cfg.DB.Port != 6000 || cfg.DB.Timeouts.Default != 2*time.Second || cfg.DB.Timeouts.Operations["GetListRecipe"] != time.Second {
+			t.Errorf("got %+v, want the values of the config file", cfg.DB)
+		}
+	})
+
+	t.Run("should report every missing and invalid setting at once", func(t *testing.T) {
+		file := writeFile(t, "config.yaml", "db:\n  hots: localhost\n")
+		env := map[string]string{"DB_PORT": "abc", "SSL_MODE": "prefer", "PUBLISH_SCHEDULER_INTERVAL": "0s", "DB_OPERATION_TIMEOUTS": "Nope=1s"}
+
+		_, _, err := Load([]string{"-config", file, "-env-file", "missing.env"}, envOf(env))
+
+		var cfgErr *Error
+		if !errors.As(err, &cfgErr) {
+			t.Fatalf("got %v, want *Error", err)
+		}
+		for _, want := range []string{"db.hots", "DB_PORT", "SSL_MODE", "PUBLISH_SCHEDULER_INTERVAL", "DB_OPERATION_TIMEOUTS", "DB_USERNAME is missing", "DB_NAME is missing"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("got %q, want it to mention %s", err.Error(), want)
+			}
+		}
+		if len(cfgErr.Problems) != 7 {
+			t.Errorf("got %d problems, want %d", len(cfgErr.Problems), 7)
+		}
+	})
+
+	t.Run("should read the policies of the routes", func(t *testing.T) {
+		file := writeFile(t, "config.yaml", `
+rate_limit:
+  default: 100/1m
+  routes:
+    "POST /recipe": 10/1m
+`)
+
+		cfg, _, err := Load([]string{"-config", file, "-env-file", "missing.env"}, envOf(required))
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		if cfg.RateLimit.Default.Limit != 100 || len(cfg.RateLimit.Routes) != 1 || cfg.RateLimit.Routes["POST /recipe"].Limit != 10 {
+			t.Errorf("got %+v, want the policies of the config file", cfg.RateLimit)
+		}
+
+		env := map[string]string{"DB_USERNAME": "root", "DB_NAME": "recipedb", "RATE_LIMIT_ROUTES": "/recipe=10/1m"}
+		if _, _, err := Load([]string{"-env-file", "missing.env"}, envOf(env)); err == nil || !strings.Contains(err.Error(), "RATE_LIMIT_ROUTES") {
+			t.Errorf("got %v, want RATE_LIMIT_ROUTES invalid", err)
+		}
+	})
+
+	t.Run("should read the digests of the api keys", func(t *testing.T) {
+		hash := "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b"
+		env := map[string]string{"DB_USERNAME": "root", "DB_NAME": "recipedb", "RATE_LIMIT_API_KEY_HASHES": strings.ToUpper(hash) + ", "}
+		if _, _, err := Load([]string{"-env-file", "missing.env"}, envOf(env)); err == nil || !strings.Contains(err.Error(), "RATE_LIMIT_API_KEY_HASHES") {
+			t.Errorf("got %v, want RATE_LIMIT_API_KEY_HASHES invalid", err)
+		}
+
+		env["RATE_LIMIT_API_KEY_HASHES"] = strings.ToUpper(hash)
+		cfg, _, err := Load([]string{"-env-file", "missing.env"}, envOf(env))
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if len(cfg.RateLimit.APIKeyHashes) != 1 || cfg.RateLimit.APIKeyHashes[0] != hash {
+			t.Errorf("got %v, want [%s]", cfg.RateLimit.APIKeyHashes, hash)
+		}
+	})
+
+	t.Run("should fail on a missing config file", func(t *testing.T) {
+		_, _, err := Load([]string{"-env-file", "missing.env"}, envOf(map[string]string{"CONFIG_FILE": "missing.yaml", "DB_USERNAME": "root", "DB_NAME": "recipedb"}))
+		if err == nil {
+			t.Errorf("got nil, want error")
+		}
+	})
+}
+
+func TestDataSourceName(t *testing.T) {
+	db := DB{Host: "localhost", Port: 5432, Username: "root", Name: "recipedb", Password: `it's a \secret`, SSLMode: "disable"}
+
+	want := `host='localhost' port=5432 user='root' dbname='recipedb' password='it\'s a \\secret' sslmode='disable'`
+	if got := db.DataSourceName(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+readFile flattens a yaml or toml file, picked by its extension, into its dotted keys, e.g.
+
+	db:
+	  host: localhost
+	  operation_timeouts:
+	    ExportRecipes: 5m
+
+is db.host=localhost and db.operation_timeouts=ExportRecipes=5m, the same values the environment takes
+*/
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("unsupported config file %s, use .yaml, .yml or .toml", filepath.Base(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	flatten("", doc, values)
+	return values, nil
+}
+
+func flatten(prefix string, doc map[string]interface{}, values map[string]string) {
+	for key, value := range doc {
+		path := prefix + key
+		table, ok := value.(map[string]interface{})
+		if !ok {
+			if value == nil {
+				value = ""
+			}
+			values[path] = fmt.Sprint(value)
+			continue
+		}
+
+		// a table naming a setting is a list of key=value pairs, the format of its environment variable
+		if _, ok := settingByPath(path); ok {
+			pairs := make([]string, 0, len(table))
+			for k, v := range table {
+				pairs = append(pairs, fmt.Sprintf("%s=%v", k, v))
+			}
+			sort.Strings(pairs)
+			values[path] = strings.Join(pairs, ",")
+			continue
+		}
+
+		flatten(path+".", table, values)
+	}
+}
@@ -0,0 +1,290 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/ratelimit"
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+/*
+setting is one key of the configuration, env names it in the environment and the dotenv file,
+path in the config file and the flag is the path with dashes, e.g. db.ssl_mode is -db-ssl-mode
+*/
+type setting struct {
+	env      string
+	path     string
+	usage    string
+	fallback string
+	required bool
+	apply    func(c *Config, value string) error
+}
+
+func (s setting) flag() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.path)
+}
+
+var settings = []setting{
+	{"APP_PORT", "app.port", "port the api listens on", "3000", false,
+		portValue(func(c *Config) *int { return &c.App.Port })},
+	{"SHUTDOWN_DRAIN", "app.shutdown_drain", "how long the app reports not ready before shutting down", "5s", false,
+		durationValue(func(c *Config) *time.Duration { return &c.App.ShutdownDrain }, false)},
+	{"SHUTDOWN_TIMEOUT", "app.shutdown_timeout", "how long the shutdown waits for the running requests", "15s", false,
+		durationValue(func(c *Config) *time.Duration { return &c.App.ShutdownTimeout }, true)},
+
+	{"DB_HOST", "db.host", "host of the postgres server", "localhost", false,
+		stringValue(func(c *Config) *string { return &c.DB.Host })},
+	{"DB_PORT", "db.port", "port of the postgres server", "5432", false,
+		portValue(func(c *Config) *int { return &c.DB.Port })},
+	{"DB_USERNAME", "db.username", "user connecting to postgres", "", true,
+		stringValue(func(c *Config) *string { return &c.DB.Username })},
+	{"DB_NAME", "db.name", "name of the database", "", true,
+		stringValue(func(c *Config) *string { return &c.DB.Name })},
+	{"DB_PASSWORD", "db.password", "password of the user", "", false,
+		stringValue(func(c *Config) *string { return &c.DB.Password })},
+	{"SSL_MODE", "db.ssl_mode", "sslmode of the connection, disable, require, verify-ca or verify-full", "require", false,
+		oneOfValue(func(c *Config) *string { return &c.DB.SSLMode }, "disable", "require", "verify-ca", "verify-full")},
+	{"DB_QUERY_TIMEOUT", "db.query_timeout", "timeout of a repository operation, 0 leaves it to the request", repository.DefaultTimeouts().Default.String(), false,
+		durationValue(func(c *Config) *time.Duration { return &c.DB.Timeouts.Default }, false)},
+	{"DB_OPERATION_TIMEOUTS", "db.operation_timeouts", "timeouts of single operations, e.g. ExportRecipes=5m,GetListRecipe=2s", "", false,
+		operationTimeoutsValue},
+	{"DB_MAX_OPEN_CONNS", "db.max_open_conns", "connections the pool opens at most, 0 is unlimited", "25", false,
+		intValue(func(c *Config) *int { return &c.DB.MaxOpenConns })},
+	{"DB_MAX_IDLE_CONNS", "db.max_idle_conns", "idle connections the pool keeps, 0 keeps none", "25", false,
+		intValue(func(c *Config) *int { return &c.DB.MaxIdleConns })},
+	{"DB_CONN_MAX_LIFETIME", "db.conn_max_lifetime", "age after which a connection is replaced, 0 keeps it forever", "30m", false,
+		durationValue(func(c *Config) *time.Duration { return &c.DB.ConnMaxLifetime }, false)},
+	{"DB_CONN_MAX_IDLE_TIME", "db.conn_max_idle_time", "idle time after which a connection is closed, 0 keeps it forever", "5m", false,
+		durationValue(func(c *Config) *time.Duration { return &c.DB.ConnMaxIdleTime }, false)},
+	{"DB_CONNECT_TIMEOUT", "db.connect_timeout", "how long the first connection is retried before giving up", "1m", false,
+		durationValue(func(c *Config) *time.Duration { return &c.DB.ConnectTimeout }, true)},
+	{"DB_CONNECT_BACKOFF", "db.connect_backoff", "wait before the first retry, doubled on every attempt", "500ms", false,
+		durationValue(func(c *Config) *time.Duration { return &c.DB.ConnectBackoff }, true)},
+	{"DB_CONNECT_BACKOFF_MAX", "db.connect_backoff_max", "longest wait between two retries", "10s", false,
+		durationValue(func(c *Config) *time.Duration { return &c.DB.ConnectBackoffMax }, true)},
+
+	{"TRASH_RETENTION", "jobs.trash_retention", "how long a deleted recipe stays in the trash", "720h", false,
+		durationValue(func(c *Config) *time.Duration { return &c.Jobs.TrashRetention }, true)},
+	{"TRASH_PURGE_INTERVAL", "jobs.trash_purge_interval", "how often the trash is purged", "1h", false,
+		durationValue(func(c *Config) *time.Duration { return &c.Jobs.TrashPurgeInterval }, true)},
+	{"PUBLISH_SCHEDULER_INTERVAL", "jobs.publish_interval", "how often the scheduled recipes are published", "1m", false,
+		durationValue(func(c *Config) *time.Duration { return &c.Jobs.PublishInterval }, true)},
+
+	{"HEALTH_CHECK_TIMEOUT", "health.check_timeout", "timeout of every dependency check of /readyz", "2s", false,
+		durationValue(func(c *Config) *time.Duration { return &c.Health.CheckTimeout }, true)},
+	{"BLOB_STORAGE_URL", "health.blob_storage_url", "url /readyz checks the blob storage on, not checked when empty", "", false,
+		urlValue(func(c *Config) *string { return &c.Health.BlobStorageURL })},
+
+	{"TRACING_EXPORTER", "tracing.exporter", "where the spans go, none, stdout or otlp", "none", false,
+		oneOfValue(func(c *Config) *string { return &c.Tracing.Exporter }, "none", "stdout", "otlp")},
+	{"TRACING_OTLP_ENDPOINT", "tracing.otlp_endpoint", "url of the otlp http collector, OTEL_EXPORTER_OTLP_ENDPOINT when empty", "", false,
+		urlValue(func(c *Config) *string { return &c.Tracing.OTLPEndpoint })},
+	{"TRACING_SAMPLE_RATIO", "tracing.sample_ratio", "share of the new traces recorded, between 0 and 1", "1", false,
+		ratioValue(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+
+	{"LOG_FORMAT", "logging.format", "json for the log collectors or text for a terminal", "json", false,
+		oneOfValue(func(c *Config) *string { return &c.Logging.Format }, "json", "text")},
+	{"LOG_LEVEL", "logging.level", "lowest level logged, debug, info, warn or error", "info", false,
+		levelValue(func(c *Config) *slog.Level { return &c.Logging.Level })},
+
+	{"RATE_LIMIT_ENABLED", "rate_limit.enabled", "whether the requests are rate limited", "true", false,
+		boolValue(func(c *Config) *bool { return &c.RateLimit.Enabled })},
+	{"RATE_LIMIT_DEFAULT", "rate_limit.default", "requests a client may send per period to the routes without their own policy, e.g. 600/1m", "600/1m", false,
+		policyValue(func(c *Config) *ratelimit.Policy { return &c.RateLimit.Default })},
+	{"RATE_LIMIT_ROUTES", "rate_limit.routes", "policies of single routes, e.g. POST /recipe=30/1m,POST /recipe/import=5/1m", "POST /recipe=30/1m,POST /recipe/import=5/1m", false,
+		routePoliciesValue},
+	{"RATE_LIMIT_API_KEY_HASHES", "rate_limit.api_key_hashes", "sha256 hex digests of the issued api keys, comma separated, an unknown key is limited by its user or ip", "", false,
+		apiKeyHashesValue},
+}
+
+func settingByPath(path string) (setting, bool) {
+	for _, s := range settings {
+		if s.path == path {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+func stringValue(field func(c *Config) *string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		*field(c) = value
+		return nil
+	}
+}
+
+func oneOfValue(field func(c *Config) *string, options ...string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		for _, option := range options {
+			if value == option {
+				*field(c) = value
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(options, ", "))
+	}
+}
+
+func urlValue(field func(c *Config) *string) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		if value == "" {
+			return nil
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("must be an http or https url")
+		}
+		*field(c) = value
+		return nil
+	}
+}
+
+func ratioValue(field func(c *Config) *float64) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return errors.New("must be a number between 0 and 1")
+		}
+		*field(c) = ratio
+		return nil
+	}
+}
+
+func levelValue(field func(c *Config) *slog.Level) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(value)); err != nil {
+			return errors.New("must be one of debug, info, warn, error")
+		}
+		*field(c) = level
+		return nil
+	}
+}
+
+func boolValue(field func(c *Config) *bool) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func policyValue(field func(c *Config) *ratelimit.Policy) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		policy, err := ratelimit.ParsePolicy(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = policy
+		return nil
+	}
+}
+
+func portValue(field func(c *Config) *int) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return errors.New("must be a port between 1 and 65535")
+		}
+		*field(c) = port
+		return nil
+	}
+}
+
+func intValue(field func(c *Config) *int) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return errors.New("must be a whole number, 0 or more")
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+// the intervals of the jobs drive a ticker and the backoff doubles, so they need to be positive where a timeout of 0 only turns it off
+func durationValue(field func(c *Config) *time.Duration, positive bool) func(c *Config, value string) error {
+	return func(c *Config, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.New("must be a duration such as 30s, 5m or 1h")
+		}
+		if d < 0 || positive && d == 0 {
+			return errors.New("must be greater than 0")
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func operationTimeoutsValue(c *Config, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		operation, duration, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return fmt.Errorf("%q is not operation=duration", pair)
+		}
+		d, err := time.ParseDuration(duration)
+		if err != nil || d < 0 {
+			return fmt.Errorf("%q is not a duration", duration)
+		}
+		if err := c.DB.Timeouts.Set(operation, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// only the digests are configured so the config never holds the api keys themselves
+func apiKeyHashesValue(c *Config, value string) error {
+	c.RateLimit.APIKeyHashes = nil
+	if value == "" {
+		return nil
+	}
+
+	for _, hash := range strings.Split(value, ",") {
+		hash = strings.ToLower(strings.TrimSpace(hash))
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != sha256.Size*2 {
+			return fmt.Errorf("%q is not a sha256 hex digest", hash)
+		}
+		c.RateLimit.APIKeyHashes = append(c.RateLimit.APIKeyHashes, hash)
+	}
+	return nil
+}
+
+// the routes are named by method and chi route pattern, the policy replaces the default one of the route
+func routePoliciesValue(c *Config, value string) error {
+	c.RateLimit.Routes = make(map[string]ratelimit.Policy)
+	if value == "" {
+		return nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		route, policy, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		method, pattern, hasPattern := strings.Cut(route, " ")
+		if !ok || !hasPattern || method != strings.ToUpper(method) || !strings.HasPrefix(pattern, "/") {
+			return fmt.Errorf("%q is not METHOD /pattern=limit/period", pair)
+		}
+
+		p, err := ratelimit.ParsePolicy(policy)
+		if err != nil {
+			return err
+		}
+		c.RateLimit.Routes[route] = p
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package entity
+
+/*
+there is no authentication layer yet, the caller is identified by the headers forwarded
+from the gateway in front of this service, the zero value is an anonymous caller
+*/
+type Caller struct {
+	Id   int64
+	Role string
+}
+
+const (
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+func (c Caller) IsAdmin() bool {
+	return c.Role == RoleAdmin
+}
+
+// CanManage is true for the author of a recipe and the editorial team, only they move it along the workflow or restore it
+func (c Caller) CanManage(ownerId *int64) bool {
+	return c.CanSeeDrafts() || (c.Id != 0 && ownerId != nil && *ownerId == c.Id)
+}
+
+// CanSeeDrafts is true for the editorial team, other callers only see published recipes and their own
+func (c Caller) CanSeeDrafts() bool {
+	return c.Role == RoleEditor || c.Role == RoleAdmin
+}
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"strconv"
+	"time"
+)
+
+// the export columns, an exported file can be imported again as is, the read only columns are ignored then
+var RecipeCSVHeader = []string{"id", "slug", "title", "description", "instruction", "status", "publish_at", "created_at", "updated_at"}
+
+func (r *Recipe) CSVRecord() []string {
+	var publishAt string
+	if r.PublishAt != nil {
+		publishAt = r.PublishAt.Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatInt(r.Id, 10),
+		r.Slug,
+		r.Title,
+		r.Description,
+		r.Instruction,
+		r.Status,
+		publishAt,
+		r.CreatedAt.Format(time.RFC3339),
+		r.UpdatedAt.Format(time.RFC3339),
+	}
+}
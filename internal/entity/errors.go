@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+)
+
+// the kinds of domain error, the api maps every kind onto its own http status
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrPrecondition = errors.New("precondition failed")
+	ErrValidation   = errors.New("validation failed")
+)
+
+/*
+Error is a domain error with a message that is safe to show to the client,
+errors.Is matches both the error itself and its kind
+*/
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func NewError(kind error, message string) *Error {
+	return &Error{Kind: kind, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError collects every invalid field of a payload so the client can fix them at once
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns nil when no field has been added, keeping the nil interface check of the callers working
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for idx, field := range e.Fields {
+		messages[idx] = field.Message
+	}
+	return strings.Join(messages, ", ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
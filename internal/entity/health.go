@@ -0,0 +1,21 @@
+package entity
+
+const (
+	HealthUp       = "up"
+	HealthDown     = "down"
+	HealthReady    = "ready"
+	HealthNotReady = "not_ready"
+	HealthDraining = "draining"
+)
+
+type HealthCheckDTO struct {
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// HealthDTO is the readiness of the app with the result of every dependency, keyed by its name
+type HealthDTO struct {
+	Status string                     `json:"status"`
+	Checks map[string]*HealthCheckDTO `json:"checks,omitempty"`
+}
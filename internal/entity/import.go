@@ -0,0 +1,46 @@
+package entity
+
+const (
+	ImportResultImported  = "imported"
+	ImportResultValid     = "valid"
+	ImportResultDuplicate = "duplicate"
+	ImportResultInvalid   = "invalid"
+	ImportResultFailed    = "failed"
+)
+
+/*
+ImportItemDTO reports what happened to one recipe of an import, index is its position in the upload,
+warnings name the parts of the source that could not be kept even though the recipe was
+*/
+type ImportItemDTO struct {
+	Index    int      `json:"index"`
+	Title    string   `json:"title,omitempty"`
+	Result   string   `json:"result"`
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type ImportReportDTO struct {
+	Imported   int              `json:"imported"`
+	Valid      int              `json:"valid"`
+	Duplicates int              `json:"duplicates"`
+	Invalid    int              `json:"invalid"`
+	Failed     int              `json:"failed"`
+	Items      []*ImportItemDTO `json:"items"`
+}
+
+func (r *ImportReportDTO) Add(item *ImportItemDTO) {
+	switch item.Result {
+	case ImportResultImported:
+		r.Imported++
+	case ImportResultValid:
+		r.Valid++
+	case ImportResultDuplicate:
+		r.Duplicates++
+	case ImportResultInvalid:
+		r.Invalid++
+	default:
+		r.Failed++
+	}
+	r.Items = append(r.Items, item)
+}
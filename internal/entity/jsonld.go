@@ -0,0 +1,52 @@
+package entity
+
+import "time"
+
+const (
+	SchemaOrgContext = "https://schema.org"
+	JSONLDMediaType  = "application/ld+json"
+)
+
+/*
+RecipeJSONLD is the schema.org Recipe used for search engine rich results,
+recipes have no times, ingredients, ratings or images yet so those properties are left out
+until they get their own tables
+*/
+type RecipeJSONLD struct {
+	Context            string       `json:"@context"`
+	Type               string       `json:"@type"`
+	Name               string       `json:"name"`
+	Description        string       `json:"description,omitempty"`
+	RecipeInstructions []*HowToStep `json:"recipeInstructions,omitempty"`
+	DateCreated        string       `json:"dateCreated,omitempty"`
+	DateModified       string       `json:"dateModified,omitempty"`
+	DatePublished      string       `json:"datePublished,omitempty"`
+}
+
+type HowToStep struct {
+	Type string `json:"@type"`
+	Text string `json:"text"`
+}
+
+func NewRecipeJSONLD(recipe *Recipe) RecipeJSONLD {
+	var steps []*HowToStep
+	for _, step := range recipe.Steps() {
+		steps = append(steps, &HowToStep{Type: "HowToStep", Text: step})
+	}
+
+	var datePublished string
+	if recipe.Status == StatusPublished && recipe.PublishAt != nil {
+		datePublished = recipe.PublishAt.Format(time.RFC3339)
+	}
+
+	return RecipeJSONLD{
+		Context:            SchemaOrgContext,
+		Type:               "Recipe",
+		Name:               recipe.Title,
+		Description:        recipe.Description,
+		RecipeInstructions: steps,
+		DateCreated:        recipe.CreatedAt.Format(time.RFC3339),
+		DateModified:       recipe.UpdatedAt.Format(time.RFC3339),
+		DatePublished:      datePublished,
+	}
+}
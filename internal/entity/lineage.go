@@ -0,0 +1,47 @@
+package entity
+
+// LineageNode is a recipe seen from another recipe's fork tree, depth is the distance between both
+type LineageNode struct {
+	Id           int64
+	Title        string
+	OwnerId      *int64
+	ForkedFromId *int64
+	Depth        int
+}
+
+type LineageNodeDTO struct {
+	Id           int64  `json:"id"`
+	Title        string `json:"title"`
+	OwnerId      *int64 `json:"owner_id,omitempty"`
+	ForkedFromId *int64 `json:"forked_from_id,omitempty"`
+	Depth        int    `json:"depth"`
+}
+
+type LineageDTO struct {
+	Recipe    LineageNodeDTO    `json:"recipe"`
+	Ancestors []*LineageNodeDTO `json:"ancestors"`
+	Forks     []*LineageNodeDTO `json:"forks"`
+}
+
+// a fork without a title gets one generated from its source, a given title follows the rules of any recipe title
+type ForkRecipeDTO struct {
+	Title *string `json:"title"`
+}
+
+func (f ForkRecipeDTO) Validate() error {
+	var v ValidationError
+	if f.Title != nil {
+		titleValidate(&v, *f.Title)
+	}
+	return v.Err()
+}
+
+func (n *LineageNode) ToDTO() *LineageNodeDTO {
+	return &LineageNodeDTO{
+		Id:           n.Id,
+		Title:        n.Title,
+		OwnerId:      n.OwnerId,
+		ForkedFromId: n.ForkedFromId,
+		Depth:        n.Depth,
+	}
+}
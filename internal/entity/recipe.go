@@ -1,48 +1,163 @@
 package entity
 
 import (
-	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+const MaxTitleLength = 100
+
 /*
 to keep it simple, the instruction will just be string to comply for the basic requirements
 for the real world application might separate each of ingredient, instruction, testimonial
 tab to different database table
 */
 type Recipe struct {
-	Id          int64
-	Title       string
-	Description string
-	Instruction string
-	Publish     *bool
-	CreatedAt   time.Time
+	Id           int64
+	Title        string
+	Slug         string
+	Description  string
+	Instruction  string
+	Status       string
+	OwnerId      *int64
+	ForkedFromId *int64
+	Version      int64
+	PublishAt    *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	DeletedAt    *time.Time
 }
 
 type RecipeDTO struct {
-	Id          int64  `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Instruction string `json:"instruction"`
-	Publish     *bool  `json:"publish,omitempty"`
-	CreatedAt   string `json:"created_at,omitempty"`
+	Id           int64  `json:"id"`
+	Title        string `json:"title"`
+	Slug         string `json:"slug,omitempty"`
+	Description  string `json:"description"`
+	Instruction  string `json:"instruction"`
+	Status       string `json:"status,omitempty"`
+	Publish      *bool  `json:"publish,omitempty"`
+	OwnerId      *int64 `json:"owner_id,omitempty"`
+	ForkedFromId *int64 `json:"forked_from_id,omitempty"`
+	Version      int64  `json:"version,omitempty"`
+	PublishAt    string `json:"publish_at,omitempty"`
+	CreatedAt    string `json:"created_at,omitempty"`
+	UpdatedAt    string `json:"updated_at,omitempty"`
+	DeletedAt    string `json:"deleted_at,omitempty"`
 }
 
 func (r RecipeDTO) InsertValidate() error {
-	if r.Title == "" {
-		return errors.New("title must not be empty")
-	}
-	return nil
+	var v ValidationError
+	titleValidate(&v, r.Title)
+	// a new recipe starts as draft and walks the workflow to the requested status, every step is audited
+	r.statusValidate(&v)
+	return v.Err()
 }
 
 func (r RecipeDTO) UpdateValidate() error {
+	var v ValidationError
 	if r.Id == 0 {
-		return errors.New("id must not be empty")
+		v.Add("id", "id must not be empty")
+	}
+	titleValidate(&v, r.Title)
+	r.statusValidate(&v)
+	return v.Err()
+}
+
+func titleValidate(v *ValidationError, title string) {
+	if title == "" {
+		v.Add("title", "title must not be empty")
+		return
+	}
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		v.Add("title", fmt.Sprintf("title must be at most %d characters", MaxTitleLength))
+	}
+}
+
+func (r RecipeDTO) statusValidate(v *ValidationError) {
+	if _, err := r.PublishAtTime(); err != nil {
+		v.Add("publish_at", "publish_at must be an RFC3339 timestamp")
+	}
+	if r.Status == "" {
+		return
+	}
+	if !IsValidStatus(r.Status) {
+		v.Add("status", "status must be one of draft, in_review, published, archived")
+		return
+	}
+	if r.Publish != nil && *r.Publish != (r.Status == StatusPublished) {
+		v.Add("publish", "status and publish are conflicting")
 	}
-	if r.Title == "" {
-		return errors.New("title must not be empty")
+}
+
+/*
+TargetStatus resolves the status requested by the payload, the legacy publish flag
+only moves a recipe in or out of published and leaves the other states untouched
+*/
+func (r RecipeDTO) TargetStatus(current string) string {
+	if r.Status != "" {
+		return r.Status
+	}
+	if r.Publish == nil {
+		return current
+	}
+	if *r.Publish && current != StatusPublished {
+		return StatusPublished
+	}
+	if !*r.Publish && current == StatusPublished {
+		return StatusDraft
+	}
+	return current
+}
+
+// a recipe is scheduled by putting it in review with a publish_at, the scheduler publishes it once due
+func (r RecipeDTO) PublishAtTime() (*time.Time, error) {
+	if r.PublishAt == "" {
+		return nil, nil
+	}
+	publishAt, err := time.Parse(time.RFC3339, r.PublishAt)
+	if err != nil {
+		return nil, err
+	}
+	return &publishAt, nil
+}
+
+func NewRecipeDTO(recipe *Recipe) RecipeDTO {
+	publish := recipe.Status == StatusPublished
+	var publishAt string
+	if recipe.PublishAt != nil {
+		publishAt = recipe.PublishAt.Format(time.RFC3339)
+	}
+	return RecipeDTO{
+		Id:           recipe.Id,
+		Title:        recipe.Title,
+		Slug:         recipe.Slug,
+		Description:  recipe.Description,
+		Instruction:  recipe.Instruction,
+		Status:       recipe.Status,
+		Publish:      &publish,
+		OwnerId:      recipe.OwnerId,
+		ForkedFromId: recipe.ForkedFromId,
+		Version:      recipe.Version,
+		PublishAt:    publishAt,
+		CreatedAt:    recipe.CreatedAt.Format("02-01-2006"),
+		UpdatedAt:    recipe.UpdatedAt.Format("02-01-2006"),
+	}
+}
+
+var stepNumber = regexp.MustCompile(`^\d+[.)]\s+`)
+
+// Steps splits the instruction into its steps, every non blank line is one step and its own numbering is dropped
+func (r *Recipe) Steps() []string {
+	var steps []string
+	for _, line := range strings.Split(r.Instruction, "\n") {
+		if line = strings.TrimSpace(stepNumber.ReplaceAllString(strings.TrimSpace(line), "")); line != "" {
+			steps = append(steps, line)
+		}
 	}
-	return nil
+	return steps
 }
 
 func (r *RecipeDTO) SetId(id int64) {
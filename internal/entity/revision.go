@@ -0,0 +1,51 @@
+package entity
+
+import "time"
+
+// Revision is an immutable snapshot of the editable content of a recipe
+type Revision struct {
+	Id          int64
+	RecipeId    int64
+	Revision    int64
+	Title       string
+	Description string
+	Instruction string
+	CreatedBy   *int64
+	CreatedAt   time.Time
+}
+
+type RevisionDTO struct {
+	Revision    int64  `json:"revision"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Instruction string `json:"instruction,omitempty"`
+	CreatedBy   *int64 `json:"created_by,omitempty"`
+	CreatedAt   string `json:"created_at,omitempty"`
+}
+
+type DiffLineDTO struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type FieldDiffDTO struct {
+	Field string         `json:"field"`
+	Lines []*DiffLineDTO `json:"lines"`
+}
+
+type RevisionDiffDTO struct {
+	From    int64           `json:"from"`
+	To      int64           `json:"to"`
+	Changes []*FieldDiffDTO `json:"changes"`
+}
+
+func (r *Revision) ToDTO() *RevisionDTO {
+	return &RevisionDTO{
+		Revision:    r.Revision,
+		Title:       r.Title,
+		Description: r.Description,
+		Instruction: r.Instruction,
+		CreatedBy:   r.CreatedBy,
+		CreatedAt:   r.CreatedAt.Format(time.RFC3339),
+	}
+}
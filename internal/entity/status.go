@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	StatusDraft     = "draft"
+	StatusInReview  = "in_review"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+/*
+the editorial workflow, a recipe goes through review before being published,
+published and archived recipes can be sent back to draft for rework
+*/
+var statusTransitions = map[string][]string{
+	StatusDraft:     {StatusInReview},
+	StatusInReview:  {StatusDraft, StatusPublished},
+	StatusPublished: {StatusArchived, StatusDraft},
+	StatusArchived:  {StatusDraft},
+}
+
+func IsValidStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+// IsPastReview tells whether a recipe in the status has been published, getting there needs an identified caller
+func IsPastReview(status string) bool {
+	return status == StatusPublished || status == StatusArchived
+}
+
+func CanTransition(from, to string) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+TransitionPath is the shortest walk along the workflow from one status to another, without from itself,
+a recipe created or published through the legacy publish flag moves this way so every step is audited,
+nil is returned when to is not reachable or is from
+*/
+func TransitionPath(from, to string) []string {
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		status := queue[0]
+		queue = queue[1:]
+		if status == to && to != from {
+			var path []string
+			for ; status != from; status = previous[status] {
+				path = append([]string{status}, path...)
+			}
+			return path
+		}
+		for _, next := range statusTransitions[status] {
+			if _, seen := previous[next]; !seen {
+				previous[next] = status
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// TransitionNotAllowed words a move the workflow does not allow the same way for the api and the cli
+func TransitionNotAllowed(from, to string) error {
+	return fmt.Errorf("recipe can not move from %s to %s", from, to)
+}
+
+type StatusTransition struct {
+	Id         int64
+	RecipeId   int64
+	FromStatus string
+	ToStatus   string
+	ChangedBy  *int64
+	ChangedAt  time.Time
+}
+
+type StatusTransitionDTO struct {
+	FromStatus string `json:"from_status,omitempty"`
+	ToStatus   string `json:"to_status"`
+	ChangedBy  *int64 `json:"changed_by,omitempty"`
+	ChangedAt  string `json:"changed_at,omitempty"`
+	Version    int64  `json:"version,omitempty"`
+}
+
+func (t StatusTransitionDTO) Validate() error {
+	var v ValidationError
+	if !IsValidStatus(t.ToStatus) {
+		v.Add("to_status", "to_status must be one of draft, in_review, published, archived")
+	}
+	return v.Err()
+}
+
+func (t *StatusTransition) ToDTO() *StatusTransitionDTO {
+	return &StatusTransitionDTO{
+		FromStatus: t.FromStatus,
+		ToStatus:   t.ToStatus,
+		ChangedBy:  t.ChangedBy,
+		ChangedAt:  t.ChangedAt.Format(time.RFC3339),
+	}
+}
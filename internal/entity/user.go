@@ -0,0 +1,30 @@
+package entity
+
+import (
+	"net/mail"
+	"time"
+	"unicode/utf8"
+)
+
+// User is an account of the service, a regular author has no role
+type User struct {
+	Id        int64
+	Email     string
+	Name      string
+	Role      string
+	CreatedAt time.Time
+}
+
+func (u User) Validate() error {
+	var v ValidationError
+	if addr, err := mail.ParseAddress(u.Email); err != nil || addr.Address != u.Email {
+		v.Add("email", "email must be a valid address")
+	}
+	if utf8.RuneCountInString(u.Name) > 100 {
+		v.Add("name", "name must be at most 100 characters")
+	}
+	if u.Role != "" && u.Role != RoleEditor && u.Role != RoleAdmin {
+		v.Add("role", "role must be one of editor, admin")
+	}
+	return v.Err()
+}
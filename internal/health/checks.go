@@ -0,0 +1,53 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/rhnauf/recipe-api/external/db"
+)
+
+func Database(pool *sql.DB) Check {
+	return Check{Name: "database", Probe: pool.PingContext}
+}
+
+// Migrations fails while the schema is behind the migrations embedded in the binary
+func Migrations(migrator *db.Migrator) Check {
+	return Check{Name: "migrations", Probe: func(ctx context.Context) error {
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		if version < migrator.Latest() {
+			return fmt.Errorf("schema is at migration %d, the binary expects %d", version, migrator.Latest())
+		}
+		return nil
+	}}
+}
+
+// HTTP fails when url does not answer or answers with a server error, e.g. the health endpoint of the blob storage
+func HTTP(name, url string, client *http.Client) Check {
+	return Check{Name: name, Probe: func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+		if err != nil {
+			return err
+		}
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+
+		if res.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("%s answered %s", url, res.Status)
+		}
+		return nil
+	}}
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+// Check probes one dependency of the app, an error marks it down
+type Check struct {
+	Name  string
+	Probe func(ctx context.Context) error
+}
+
+/*
+Checker tells whether the app can take traffic, every check runs concurrently with its own timeout
+so one dependency hanging does not hide the state of the others
+*/
+type Checker struct {
+	timeout  time.Duration
+	checks   []Check
+	draining atomic.Bool
+}
+
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{timeout: timeout, checks: checks}
+}
+
+// Drain makes the app report not ready from now on, so the load balancer stops routing to it before the shutdown
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Ready runs the checks, the app is ready when none of them fails and it is not draining
+func (c *Checker) Ready(ctx context.Context) (*entity.HealthDTO, bool) {
+	if c.draining.Load() {
+		return &entity.HealthDTO{Status: entity.HealthDraining}, false
+	}
+
+	res := &entity.HealthDTO{Status: entity.HealthReady, Checks: make(map[string]*entity.HealthCheckDTO, len(c.checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := c.run(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			res.Checks[check.Name] = result
+			if result.Status == entity.HealthDown {
+				res.Status = entity.HealthNotReady
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return res, res.Status == entity.HealthReady
+}
+
+func (c *Checker) run(ctx context.Context, check Check) *entity.HealthCheckDTO {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Probe(ctx)
+
+	result := &entity.HealthCheckDTO{Status: entity.HealthUp, DurationMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = entity.HealthDown
+		result.Error = err.Error()
+	}
+	return result
+}
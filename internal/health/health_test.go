@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func probeOf(err error) func(ctx context.Context) error {
+	return func(ctx context.Context) error { return err }
+}
+
+func TestChecker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should be ready when every check is up", func(t *testing.T) {
+		c := NewChecker(time.Second, Check{"database", probeOf(nil)}, Check{"migrations", probeOf(nil)})
+
+		res, ready := c.Ready(ctx)
+
+		if !ready || res.Status != entity.HealthReady {
+			t.Errorf("got %s, want %s", res.Status, entity.HealthReady)
+		}
+		if len(res.Checks) != 2 || res.Checks["database"].Status != entity.HealthUp {
+			t.Errorf("got %v, want both checks up", res.Checks)
+		}
+	})
+
+	t.Run("should not be ready when a check is down", func(t *testing.T) {
+		c := NewChecker(time.Second, Check{"database", probeOf(sql.ErrConnDone)}, Check{"migrations", probeOf(nil)})
+
+		res, ready := c.Ready(ctx)
+
+		if ready || res.Status != entity.HealthNotReady {
+			t.Errorf("got %s, want %s", res.Status, entity.HealthNotReady)
+		}
+		if res.Checks["database"].Status != entity.HealthDown || res.Checks["database"].Error != sql.ErrConnDone.Error() {
+			t.Errorf("got %v, want database down with its error", res.Checks["database"])
+		}
+		if res.Checks["migrations"].Status != entity.HealthUp {
+			t.Errorf("got %v, want migrations up", res.Checks["migrations"])
+		}
+	})
+
+	t.Run("should cut a hanging check at the timeout", func(t *testing.T) {
+		hanging := func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		c := NewChecker(10*time.Millisecond, Check{"blob_storage", hanging})
+
+		start := time.Now()
+		_, ready := c.Ready(ctx)
+
+		if ready {
+			t.Errorf("got ready, want not ready")
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("got %v, want the check cut at its timeout", elapsed)
+		}
+	})
+
+	t.Run("should not be ready while draining", func(t *testing.T) {
+		c := NewChecker(time.Second, Check{"database", probeOf(nil)})
+		c.Drain()
+
+		res, ready := c.Ready(ctx)
+
+		if ready || res.Status != entity.HealthDraining {
+			t.Errorf("got %s, want %s", res.Status, entity.HealthDraining)
+		}
+	})
+}
+
+func TestHTTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"should be up on 200", http.StatusOK, false},
+		{"should be up on 403, the storage answers", http.StatusForbidden, false},
+		{"should be down on 503", http.StatusServiceUnavailable, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			err := HTTP("blob_storage", srv.URL, srv.Client()).Probe(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
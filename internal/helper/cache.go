@@ -0,0 +1,67 @@
+package helper
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	CacheControlRevalidate        = "public, no-cache"
+	CacheControlPrivateRevalidate = "private, no-cache"
+)
+
+/*
+SetCacheHeaders lets caches store the response as long as they revalidate it on every use,
+a private response is only kept by the cache of the client, never by a shared one
+*/
+func SetCacheHeaders(w http.ResponseWriter, etag string, lastModified time.Time, private bool) {
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if private {
+		w.Header().Set("Cache-Control", CacheControlPrivateRevalidate)
+		return
+	}
+	w.Header().Set("Cache-Control", CacheControlRevalidate)
+}
+
+/*
+IsNotModified evaluates the conditional GET headers, If-None-Match takes precedence
+and If-Modified-Since is only looked at when the client did not send any entity tag
+*/
+func IsNotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || weakETag(candidate) == weakETag(etag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		// http dates only have second precision
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+func HandleNotModified(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotModified)
+}
+
+func weakETag(etag string) string {
+	return strings.TrimPrefix(etag, "W/")
+}
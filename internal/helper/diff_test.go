@@ -0,0 +1,43 @@
+package helper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want []DiffLine
+	}{
+		{"should keep equal texts equal", "a\nb", "a\nb", []DiffLine{{DiffEqual, "a"}, {DiffEqual, "b"}}},
+		{"should insert every line into an empty text", "", "a\nb", []DiffLine{{DiffInsert, "a"}, {DiffInsert, "b"}}},
+		{"should delete every line of an emptied text", "a\nb", "", []DiffLine{{DiffDelete, "a"}, {DiffDelete, "b"}}},
+		{"should diff the changed line only", "a\nb\nc", "a\nx\nc", []DiffLine{{DiffEqual, "a"}, {DiffDelete, "b"}, {DiffInsert, "x"}, {DiffEqual, "c"}}},
+		{"should insert a line in the middle", "a\nc", "a\nb\nc", []DiffLine{{DiffEqual, "a"}, {DiffInsert, "b"}, {DiffEqual, "c"}}},
+		{"should treat crlf line endings as lf", "a\r\nb", "a\nb", []DiffLine{{DiffEqual, "a"}, {DiffEqual, "b"}}},
+		{"should return nothing for two empty texts", "", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffLines(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("should diff texts too long for the table as a whole", func(t *testing.T) {
+		from := strings.Repeat("a\n", 600) + "same"
+		to := strings.Repeat("b\n", 600) + "same"
+
+		got := DiffLines(from, to)
+
+		if len(got) != 1202 || got[0].Op != DiffDelete || got[600] != (DiffLine{DiffDelete, "same"}) || got[601].Op != DiffInsert || got[1201] != (DiffLine{DiffInsert, "same"}) {
+			t.Errorf("got %d lines, want every line deleted then inserted", len(got))
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package helper
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidETag = errors.New("invalid entity tag")
+
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+/*
+ParseIfMatch returns the version the client expects, zero means the header is absent
+or a wildcard and the write should not be conditional
+*/
+func ParseIfMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	// weak tags never match on If-Match, the version tags are always strong
+	if !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || len(header) < 3 {
+		return 0, ErrInvalidETag
+	}
+
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, ErrInvalidETag
+	}
+
+	return version, nil
+}
+
+// ListETag is a weak tag since the list is a projection and not a byte identical representation
+func ListETag(hash []byte) string {
+	return `W/"` + hex.EncodeToString(hash) + `"`
+}
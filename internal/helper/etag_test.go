@@ -0,0 +1,49 @@
+package helper
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	if got := ETag(3); got != `"3"` {
+		t.Errorf("got %s, want %s", got, `"3"`)
+	}
+	if got := VariantETag(3, "md"); got != `"3+md"` {
+		t.Errorf("got %s, want %s", got, `"3+md"`)
+	}
+	if got := ListETag([]byte{0xab, 0x01}); got != `W/"ab01"` {
+		t.Errorf("got %s, want %s", got, `W/"ab01"`)
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    int64
+		wantErr error
+	}{
+		{"should not be conditional without the header", "", 0, nil},
+		{"should not be conditional on a wildcard", "*", 0, nil},
+		{"should return the version of the tag", `"2"`, 2, nil},
+		{"should trim the surrounding spaces", ` "2" `, 2, nil},
+		{"should reject a weak tag", `W/"2"`, 0, ErrInvalidETag},
+		{"should reject an unquoted tag", "2", 0, ErrInvalidETag},
+		{"should reject an empty tag", `""`, 0, ErrInvalidETag},
+		{"should reject a tag that is not a version", `"abc"`, 0, ErrInvalidETag},
+		{"should reject a variant tag", `"2+md"`, 0, ErrInvalidETag},
+		{"should reject version zero", `"0"`, 0, ErrInvalidETag},
+		{"should reject a negative version", `"-1"`, 0, ErrInvalidETag},
+		{"should reject a list of tags", `"1", "2"`, 0, ErrInvalidETag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIfMatch(tt.header)
+			if got != tt.want || !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %d %v, want %d %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
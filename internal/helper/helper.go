@@ -30,10 +30,11 @@ func HandleResponse(w http.ResponseWriter, statusCode int, message string, data
 }
 
 func HandleInternalServerError(w http.ResponseWriter) {
-	res := &Response{
-		StatusCode: http.StatusInternalServerError,
-		Message:    "internal server error",
-		Data:       nil,
+	res := &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+		Detail: "internal server error",
 	}
 
 	js, err := json.Marshal(res)
@@ -41,7 +42,7 @@ func HandleInternalServerError(w http.ResponseWriter) {
 		log.Fatalf("error marshalling response")
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", ProblemMediaType)
 	w.WriteHeader(http.StatusInternalServerError)
 	w.Write(js)
 }
@@ -0,0 +1,89 @@
+package helper
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+Negotiate picks the offered media type the Accept header prefers, the first offer is the default
+when the header is missing or only has wildcards, an empty string means none of the offers is acceptable
+*/
+func Negotiate(r *http.Request, offers ...string) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return offers[0]
+	}
+
+	best, bestQ, bestSpecific := "", 0.0, false
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, q := parseAcceptPart(part)
+		if q <= 0 {
+			continue
+		}
+
+		for _, offer := range offers {
+			specific := mediaType == offer
+			if !specific && !acceptsWildcard(mediaType, offer) {
+				continue
+			}
+			if q > bestQ || (q == bestQ && specific && !bestSpecific) {
+				best, bestQ, bestSpecific = offer, q, specific
+			}
+			// a wildcard matches every offer, the first one is the preferred
+			if !specific {
+				break
+			}
+		}
+	}
+
+	return best
+}
+
+// VariantETag tags a representation other than the default json one, each variant needs its own strong tag
+func VariantETag(version int64, variant string) string {
+	return `"` + strconv.FormatInt(version, 10) + "+" + variant + `"`
+}
+
+// HandleRaw writes the data as is without the response envelope, for formats defined by someone else
+func HandleRaw(w http.ResponseWriter, statusCode int, contentType string, data interface{}) {
+	js, err := json.Marshal(data)
+	if err != nil {
+		HandleInternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	w.Write(js)
+}
+
+func parseAcceptPart(part string) (string, float64) {
+	params := strings.Split(part, ";")
+	mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+
+	q := 1.0
+	for _, param := range params[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.ToLower(key) != "q" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return mediaType, 0
+		}
+		q = parsed
+	}
+
+	return mediaType, q
+}
+
+func acceptsWildcard(mediaType, offer string) bool {
+	if mediaType == "*/*" {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaType, "/*")
+	return ok && strings.HasPrefix(offer, prefix+"/")
+}
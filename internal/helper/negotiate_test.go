@@ -0,0 +1,42 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "text/markdown", "text/html"}
+
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{"should default to the first offer without the header", "", "application/json"},
+		{"should pick the requested type", "text/html", "text/html"},
+		{"should ignore the case of the media type", "TEXT/HTML", "text/html"},
+		{"should pick the highest quality", "text/markdown;q=0.5, text/html;q=0.9", "text/html"},
+		{"should default to the first offer on a full wildcard", "*/*", "application/json"},
+		{"should pick the first offer of a type wildcard", "text/*", "text/markdown"},
+		{"should prefer a specific type over a wildcard of the same quality", "*/*, text/html", "text/html"},
+		{"should prefer a wildcard of higher quality", "text/html;q=0.2, */*", "application/json"},
+		{"should refuse a type with zero quality", "text/html;q=0", ""},
+		{"should refuse a type with a malformed quality", "text/html;q=high", ""},
+		{"should return none when no offer is acceptable", "application/xml", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/recipe/1", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			if got := Negotiate(req, offers...); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
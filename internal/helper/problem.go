@@ -0,0 +1,61 @@
+package helper
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const ProblemMediaType = "application/problem+json"
+
+/*
+Problem is an RFC 7807 problem details body, the type is left as about:blank so the title is the
+reason phrase of the status, extension members are written next to the standard members
+*/
+type Problem struct {
+	Type       string                 `json:"type"`
+	Title      string                 `json:"title"`
+	Status     int                    `json:"status"`
+	Detail     string                 `json:"detail,omitempty"`
+	Instance   string                 `json:"instance,omitempty"`
+	Extensions map[string]interface{} `json:"-"`
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type standard Problem
+	js, err := json.Marshal(standard(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return js, err
+	}
+
+	extensions, err := json.Marshal(p.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	if len(extensions) == 2 {
+		return js, nil
+	}
+
+	// both are json objects, the extensions are spliced in before the closing brace
+	return append(append(js[:len(js)-1], ','), extensions[1:]...), nil
+}
+
+func HandleProblem(w http.ResponseWriter, r *http.Request, statusCode int, detail string, extensions map[string]interface{}) {
+	problem := Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(statusCode),
+		Status:     statusCode,
+		Detail:     detail,
+		Instance:   r.URL.Path,
+		Extensions: extensions,
+	}
+
+	js, err := json.Marshal(problem)
+	if err != nil {
+		HandleInternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemMediaType)
+	w.WriteHeader(statusCode)
+	w.Write(js)
+}
@@ -0,0 +1,75 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{"should lowercase and join the words with dashes", "  Nasi   Goreng!! ", "nasi-goreng"},
+		{"should transliterate the accented letters", "Crème Brûlée & Co", "creme-brulee-and-co"},
+		{"should transliterate letters to more than one letter", "Smørrebrød Straße", "smorrebrod-strasse"},
+		{"should keep the digits", "Soto 2", "soto-2"},
+		{"should drop the letters without a transliteration", "Bakso 北京", "bakso"},
+		{"should fall back when nothing is left", "北京烤鸭", "recipe"},
+		{"should fall back on an empty title", "", "recipe"},
+		{"should leave room for the collision suffix", strings.Repeat("a", 150), strings.Repeat("a", MaxSlugLength-1)},
+		{"should not end with a dash when cut", strings.Repeat("a", 97) + " bc", strings.Repeat("a", 97) + "-b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.title); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasSlugBase(t *testing.T) {
+	tests := []struct {
+		name string
+		slug string
+		want bool
+	}{
+		{"should match the base itself", "nasi-goreng", true},
+		{"should match a collision suffix", "nasi-goreng-12", true},
+		{"should not match the suffix one", "nasi-goreng-1", false},
+		{"should not match a zero padded suffix", "nasi-goreng-02", false},
+		{"should not match another word", "nasi-goreng-spesial", false},
+		{"should not match a shorter slug", "nasi", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasSlugBase(tt.slug, "nasi-goreng"); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextSlug(t *testing.T) {
+	tests := []struct {
+		name  string
+		taken map[string]bool
+		want  string
+	}{
+		{"should return the base when it is free", map[string]bool{"nasi-goreng-2": true}, "nasi-goreng"},
+		{"should suffix a taken base starting at two", map[string]bool{"nasi-goreng": true}, "nasi-goreng-2"},
+		{"should take the first free suffix", map[string]bool{"nasi-goreng": true, "nasi-goreng-2": true, "nasi-goreng-4": true}, "nasi-goreng-3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextSlug("nasi-goreng", tt.taken); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
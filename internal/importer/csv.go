@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+var ErrMissingColumn = errors.New("csv column not found")
+
+// Columns maps a recipe field onto the csv header naming it, unmapped fields are looked up by their default names
+type Columns map[string]string
+
+var defaultColumns = map[string][]string{
+	"title":       {"title", "name"},
+	"description": {"description"},
+	"instruction": {"instruction", "instructions"},
+	"status":      {"status"},
+	"publish_at":  {"publish_at"},
+}
+
+// CSVFields lists the recipe fields a csv column can be mapped onto
+var CSVFields = []string{"title", "description", "instruction", "status", "publish_at"}
+
+// CSVSource reads the rows one at a time so a large catalog is never held in memory as a whole
+type CSVSource struct {
+	reader  *csv.Reader
+	indexes map[string]int
+}
+
+// NewCSVSource reads the header, the title column is required, the other ones are optional
+func NewCSVSource(r io.Reader, columns Columns) (*CSVSource, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]int, len(header))
+	for idx, name := range header {
+		if idx == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		positions[strings.ToLower(strings.TrimSpace(name))] = idx
+	}
+
+	indexes := make(map[string]int)
+	for _, field := range CSVFields {
+		names := defaultColumns[field]
+		if name, ok := columns[field]; ok {
+			names = []string{name}
+		}
+
+		found := false
+		for _, name := range names {
+			if idx, ok := positions[strings.ToLower(strings.TrimSpace(name))]; ok {
+				indexes[field] = idx
+				found = true
+				break
+			}
+		}
+		if !found && (field == "title" || columns[field] != "") {
+			return nil, fmt.Errorf("%w: %s", ErrMissingColumn, names[0])
+		}
+	}
+
+	return &CSVSource{reader: reader, indexes: indexes}, nil
+}
+
+// a row with the wrong number of fields is reported as an invalid item, any other csv error ends the import
+func (s *CSVSource) Next() (*Item, error) {
+	record, err := s.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			return &Item{Err: fmt.Errorf("line %d has %d fields, want %d", parseErr.Line, len(record), s.reader.FieldsPerRecord)}, nil
+		}
+		return nil, err
+	}
+
+	return newItem(entity.RecipeDTO{
+		Title:       s.field(record, "title"),
+		Description: s.field(record, "description"),
+		Instruction: s.field(record, "instruction"),
+		Status:      s.field(record, "status"),
+		PublishAt:   s.field(record, "publish_at"),
+	}), nil
+}
+
+func (s *CSVSource) field(record []string, field string) string {
+	idx, ok := s.indexes[field]
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(record[idx])
+}
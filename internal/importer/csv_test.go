@@ -0,0 +1,60 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func TestCSVSource(t *testing.T) {
+	t.Run("should read rows by the default column names", func(t *testing.T) {
+		body := "\ufeffName,Instructions,publish_at,status\n" +
+			"soto ayam,boil chicken,2024-05-01T10:00:00Z,in_review\n"
+
+		source, err := NewCSVSource(strings.NewReader(body), nil)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		item, err := source.Next()
+		if err != nil || item.Err != nil {
+			t.Fatalf("got %v %v, want a valid item", err, item.Err)
+		}
+		got := item.Recipe
+		if got.Title != "soto ayam" || got.Instruction != "boil chicken" || got.Status != entity.StatusInReview || got.PublishAt == nil {
+			t.Errorf("got %+v, want the mapped recipe", got)
+		}
+
+		if _, err := source.Next(); !errors.Is(err, io.EOF) {
+			t.Errorf("got %v, want %v", err, io.EOF)
+		}
+	})
+
+	t.Run("should report rows breaking the insert rules", func(t *testing.T) {
+		body := "title,publish_at\n" +
+			"soto ayam,tomorrow\n" +
+			strings.Repeat("a", entity.MaxTitleLength+1) + ",\n"
+
+		source, err := NewCSVSource(strings.NewReader(body), nil)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		for _, want := range []string{"publish_at must be an RFC3339 timestamp", "title must be at most 100 characters"} {
+			item, err := source.Next()
+			if err != nil || item.Err == nil || item.Err.Error() != want {
+				t.Errorf("got %v %v, want %q", err, item, want)
+			}
+		}
+	})
+
+	t.Run("should return error missing column without title", func(t *testing.T) {
+		_, err := NewCSVSource(strings.NewReader("description\nclear soup\n"), nil)
+		if !errors.Is(err, ErrMissingColumn) {
+			t.Errorf("got %v, want %v", err, ErrMissingColumn)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/logging"
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+var (
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrNoRecipe             = errors.New("no schema.org recipe found")
+)
+
+// Item is one recipe found in an upload, Err is set when it could not be mapped onto a recipe
+type Item struct {
+	Recipe   entity.Recipe
+	Err      error
+	Warnings []string
+}
+
+// Source yields the items of an upload one at a time, io.EOF marks the end
+type Source interface {
+	Next() (*Item, error)
+}
+
+type sliceSource struct {
+	items []*Item
+}
+
+func (s *sliceSource) Next() (*Item, error) {
+	if len(s.items) == 0 {
+		return nil, io.EOF
+	}
+	item := s.items[0]
+	s.items = s.items[1:]
+	return item, nil
+}
+
+// Importer stores parsed recipes, one item failing never stops the rest of the import
+type Importer struct {
+	recipeRepository repository.RecipeRepository
+}
+
+func NewImporter(recipeRepository repository.RecipeRepository) *Importer {
+	return &Importer{recipeRepository: recipeRepository}
+}
+
+/*
+Parse reads the recipes of an html page with embedded json-ld, a plain json-ld document or a csv file,
+columns maps the csv header onto the recipe fields and is ignored for the other formats
+*/
+func Parse(mediaType string, r io.Reader, columns Columns) (Source, error) {
+	var items []*Item
+	var err error
+
+	switch mediaType {
+	case "text/csv":
+		return NewCSVSource(r, columns)
+	case "text/html", "application/xhtml+xml":
+		items, err = ParseHTML(r)
+	case entity.JSONLDMediaType, "application/json":
+		items, err = ParseJSONLD(r)
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNoRecipe
+	}
+
+	return &sliceSource{items: items}, nil
+}
+
+/*
+Import inserts every valid item owned by ownerId, titles already taken by a recipe outside the trash
+are reported as duplicates and left alone, a dry run only validates, the report is returned along
+with the error when the source breaks off halfway so the caller knows what has been stored
+*/
+func (i *Importer) Import(ctx context.Context, source Source, ownerId *int64, dryRun bool) (*entity.ImportReportDTO, error) {
+	report := &entity.ImportReportDTO{Items: []*entity.ImportItemDTO{}}
+
+	for idx := 0; ; idx++ {
+		// a client that went away stops the import instead of failing every remaining row
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		item, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+
+		result := &entity.ImportItemDTO{Index: idx, Title: item.Recipe.Title, Warnings: item.Warnings}
+
+		switch {
+		case item.Err != nil:
+			result.Result = entity.ImportResultInvalid
+			result.Error = item.Err.Error()
+		case entity.IsPastReview(item.Recipe.Status) && ownerId == nil:
+			// an exported published or archived recipe walks through review on behalf of the importing author
+			result.Result = entity.ImportResultInvalid
+			result.Error = "user id is required to import a " + item.Recipe.Status + " recipe"
+		case dryRun:
+			result.Result = entity.ImportResultValid
+		default:
+			i.insert(ctx, item.Recipe, ownerId, result)
+		}
+		report.Add(result)
+	}
+}
+
+func (i *Importer) insert(ctx context.Context, recipe entity.Recipe, ownerId *int64, result *entity.ImportItemDTO) {
+	if recipe.Status == "" {
+		recipe.Status = entity.StatusDraft
+	}
+	recipe.OwnerId = ownerId
+
+	err := i.recipeRepository.InsertRecipe(ctx, recipe)
+	switch {
+	case err == nil:
+		result.Result = entity.ImportResultImported
+	case errors.Is(err, repository.ErrDuplicateTitle):
+		result.Result = entity.ImportResultDuplicate
+		result.Error = err.Error()
+	default:
+		logging.FromContext(ctx).Error("error insert imported recipe", "error", err, "index", result.Index)
+		result.Result = entity.ImportResultFailed
+		result.Error = "error insert recipe"
+	}
+}
+
+// newItem maps a payload onto a recipe by the same rules as inserting one through the api
+func newItem(dto entity.RecipeDTO) *Item {
+	item := &Item{Recipe: entity.Recipe{Title: dto.Title}}
+
+	if err := dto.InsertValidate(); err != nil {
+		item.Err = err
+		return item
+	}
+
+	publishAt, _ := dto.PublishAtTime()
+	item.Recipe = entity.Recipe{
+		Title:       dto.Title,
+		Description: dto.Description,
+		Instruction: dto.Instruction,
+		Status:      dto.TargetStatus(entity.StatusDraft),
+		PublishAt:   publishAt,
+	}
+	return item
+}
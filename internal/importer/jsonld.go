@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+var (
+	jsonLDScript = regexp.MustCompile(`(?is)<script[^>]+type\s*=\s*["']?application/ld\+json["']?[^>]*>(.*?)</script>`)
+	htmlTag      = regexp.MustCompile(`<[^>]*>`)
+	whitespace   = regexp.MustCompile(`\s+`)
+)
+
+// ParseHTML reads every json-ld script of the page, a script that is not valid json is reported as an invalid item
+func ParseHTML(r io.Reader) ([]*Item, error) {
+	page, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*Item
+	for idx, match := range jsonLDScript.FindAllSubmatch(page, -1) {
+		var doc interface{}
+		if err := json.Unmarshal(match[1], &doc); err != nil {
+			items = append(items, &Item{Err: fmt.Errorf("json-ld script %d is not valid json", idx+1)})
+			continue
+		}
+		items = collectRecipes(doc, items)
+	}
+
+	return items, nil
+}
+
+func ParseJSONLD(r io.Reader) ([]*Item, error) {
+	var doc interface{}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return collectRecipes(doc, nil), nil
+}
+
+// recipes can be the document itself, part of a list or nested in an @graph
+func collectRecipes(node interface{}, items []*Item) []*Item {
+	switch v := node.(type) {
+	case []interface{}:
+		for _, child := range v {
+			items = collectRecipes(child, items)
+		}
+	case map[string]interface{}:
+		if hasType(v, "Recipe") {
+			return append(items, mapRecipe(v))
+		}
+		if graph, ok := v["@graph"]; ok {
+			items = collectRecipes(graph, items)
+		}
+	}
+	return items
+}
+
+// the properties a recipe has no column for yet, reported as warnings when the source has them
+var droppedProperties = []string{"recipeYield", "prepTime", "cookTime", "totalTime", "image", "nutrition", "aggregateRating"}
+
+/*
+mapRecipe maps the schema.org vocabulary onto a recipe, the steps of recipeInstructions become
+the lines of the instruction and the ingredients are listed after the description, the properties
+without a place to go are named in the warnings of the item
+*/
+func mapRecipe(v map[string]interface{}) *Item {
+	description := cleanText(text(v["description"]))
+	if ingredients := instructions(v["recipeIngredient"], nil); len(ingredients) > 0 {
+		description = strings.TrimSpace(description + "\n\nIngredients:\n- " + strings.Join(ingredients, "\n- "))
+	}
+
+	item := newItem(entity.RecipeDTO{
+		Title:       cleanText(text(v["name"])),
+		Description: description,
+		Instruction: strings.Join(instructions(v["recipeInstructions"], nil), "\n"),
+	})
+	for _, property := range droppedProperties {
+		if _, ok := v[property]; ok {
+			item.Warnings = append(item.Warnings, property+" is not imported")
+		}
+	}
+	return item
+}
+
+// instructions flattens plain text, lists of steps and sections into one line per step
+func instructions(node interface{}, lines []string) []string {
+	switch v := node.(type) {
+	case string:
+		for _, line := range strings.Split(v, "\n") {
+			if line = cleanText(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			lines = instructions(child, lines)
+		}
+	case map[string]interface{}:
+		if hasType(v, "HowToSection") {
+			if name := cleanText(text(v["name"])); name != "" {
+				lines = append(lines, name)
+			}
+			return instructions(v["itemListElement"], lines)
+		}
+		step := text(v["text"])
+		if step == "" {
+			step = text(v["name"])
+		}
+		lines = instructions(step, lines)
+	}
+	return lines
+}
+
+func hasType(v map[string]interface{}, want string) bool {
+	var types []interface{}
+	switch t := v["@type"].(type) {
+	case string:
+		types = []interface{}{t}
+	case []interface{}:
+		types = t
+	}
+
+	for _, t := range types {
+		name, _ := t.(string)
+		if name == want || strings.HasSuffix(name, "/"+want) || strings.HasSuffix(name, ":"+want) {
+			return true
+		}
+	}
+	return false
+}
+
+// text reads a plain string property, also when it is given as a value object or a list
+func text(node interface{}) string {
+	switch v := node.(type) {
+	case string:
+		return v
+	case []interface{}:
+		if len(v) > 0 {
+			return text(v[0])
+		}
+	case map[string]interface{}:
+		return text(v["@value"])
+	}
+	return ""
+}
+
+func cleanText(s string) string {
+	s = html.UnescapeString(htmlTag.ReplaceAllString(s, " "))
+	return strings.TrimSpace(whitespace.ReplaceAllString(s, " "))
+}
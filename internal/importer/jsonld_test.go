@@ -0,0 +1,109 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONLD(t *testing.T) {
+	t.Run("should map a recipe with steps and sections", func(t *testing.T) {
+		doc := `{
+			"@context": "https://schema.org",
+			"@type": "Recipe",
+			"name": "Nasi Goreng &amp; Telur",
+			"description": "<p>fried   rice</p>",
+			"recipeIngredient": ["rice", "egg"],
+			"recipeYield": "2 servings",
+			"image": "https://example.com/nasi-goreng.jpg",
+			"recipeInstructions": [
+				{"@type": "HowToStep", "text": "cook rice"},
+				{"@type": "HowToSection", "name": "Finish", "itemListElement": [
+					{"@type": "HowToStep", "name": "add egg"}
+				]}
+			]
+		}`
+
+		items, err := ParseJSONLD(strings.NewReader(doc))
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if len(items) != 1 {
+			t.Fatalf("got %d items, want %d", len(items), 1)
+		}
+
+		got := items[0].Recipe
+		if got.Title != "Nasi Goreng & Telur" || got.Description != "fried rice\n\nIngredients:\n- rice\n- egg" || got.Instruction != "cook rice\nFinish\nadd egg" {
+			t.Errorf("got %+v, want the mapped recipe", got)
+		}
+		if want := []string{"recipeYield is not imported", "image is not imported"}; !reflect.DeepEqual(items[0].Warnings, want) {
+			t.Errorf("got %v, want %v", items[0].Warnings, want)
+		}
+	})
+
+	t.Run("should find recipes in a graph", func(t *testing.T) {
+		doc := `{"@graph": [{"@type": "WebPage", "name": "page"}, {"@type": ["Recipe"], "name": "rendang", "recipeInstructions": "slow cook\n\nserve"}]}`
+
+		items, err := ParseJSONLD(strings.NewReader(doc))
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if len(items) != 1 || items[0].Recipe.Title != "rendang" || items[0].Recipe.Instruction != "slow cook\nserve" {
+			t.Errorf("got %+v, want recipe rendang", items)
+		}
+	})
+
+	t.Run("should report a recipe without name", func(t *testing.T) {
+		items, err := ParseJSONLD(strings.NewReader(`[{"@type": "Recipe"}]`))
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+		if len(items) != 1 || items[0].Err == nil {
+			t.Errorf("got %+v, want one invalid item", items)
+		}
+	})
+
+	t.Run("should return error on invalid json", func(t *testing.T) {
+		if _, err := ParseJSONLD(strings.NewReader(`{`)); err == nil {
+			t.Errorf("got nil, want error")
+		}
+	})
+}
+
+func TestParseHTML(t *testing.T) {
+	page := `<html><head>
+		<script type="application/ld+json">{"@type": "Recipe", "name": "soto ayam"}</script>
+		<script type='application/ld+json'>{not json}</script>
+		<script>var x = 1;</script>
+	</head></html>`
+
+	items, err := ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want %d", len(items), 2)
+	}
+	if items[0].Recipe.Title != "soto ayam" || items[0].Err != nil {
+		t.Errorf("got %+v, want recipe soto ayam", items[0])
+	}
+	if items[1].Err == nil {
+		t.Errorf("got nil, want error for the invalid script")
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Run("should return error unsupported media type", func(t *testing.T) {
+		_, err := Parse("text/plain", strings.NewReader(""), nil)
+		if err != ErrUnsupportedMediaType {
+			t.Errorf("got %v, want %v", err, ErrUnsupportedMediaType)
+		}
+	})
+
+	t.Run("should return error when there is no recipe", func(t *testing.T) {
+		_, err := Parse("text/html", strings.NewReader("<html></html>"), nil)
+		if err != ErrNoRecipe {
+			t.Errorf("got %v, want %v", err, ErrNoRecipe)
+		}
+	})
+}
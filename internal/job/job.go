@@ -0,0 +1,22 @@
+package job
+
+import (
+	"context"
+	"time"
+)
+
+// every runs fn once right away then on every interval until the context is cancelled, fn gets the context so a run in flight is cancelled too
+func every(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+const publishBatchSize = 100
+
+/*
+ScheduledPublisher publishes the recipes in review whose publish_at has passed,
+the due rows are locked with SKIP LOCKED so every instance can run it safely
+*/
+type ScheduledPublisher struct {
+	recipeRepository repository.RecipeRepository
+	interval         time.Duration
+}
+
+func NewScheduledPublisher(recipeRepository repository.RecipeRepository, interval time.Duration) *ScheduledPublisher {
+	return &ScheduledPublisher{
+		recipeRepository: recipeRepository,
+		interval:         interval,
+	}
+}
+
+func (p *ScheduledPublisher) Run(ctx context.Context) {
+	every(ctx, p.interval, p.publish)
+}
+
+func (p *ScheduledPublisher) publish(ctx context.Context) {
+	for {
+		published, err := p.recipeRepository.PublishDueRecipes(ctx, publishBatchSize)
+		if err != nil {
+			slog.ErrorContext(ctx, "error publishing scheduled recipes", "error", err)
+			return
+		}
+		if published > 0 {
+			slog.InfoContext(ctx, "published scheduled recipes", "count", published)
+		}
+
+		// a full batch means there might be more due recipes waiting
+		if published < publishBatchSize {
+			return
+		}
+	}
+}
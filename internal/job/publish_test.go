@@ -0,0 +1,46 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+type mockPublishRepository struct {
+	repository.RecipeRepository
+	batches []int64
+	calls   int
+}
+
+func (m *mockPublishRepository) PublishDueRecipes(ctx context.Context, limit int) (int64, error) {
+	if m.calls >= len(m.batches) {
+		return 0, sql.ErrConnDone
+	}
+	published := m.batches[m.calls]
+	m.calls++
+	return published, nil
+}
+
+func TestScheduledPublisher(t *testing.T) {
+	t.Run("should keep publishing while batches are full", func(t *testing.T) {
+		repo := &mockPublishRepository{batches: []int64{publishBatchSize, publishBatchSize, 3}}
+
+		NewScheduledPublisher(repo, 0).publish(context.Background())
+
+		if repo.calls != 3 {
+			t.Errorf("got %d calls, want %d", repo.calls, 3)
+		}
+	})
+
+	t.Run("should stop on error", func(t *testing.T) {
+		repo := &mockPublishRepository{}
+
+		NewScheduledPublisher(repo, 0).publish(context.Background())
+
+		if repo.calls != 0 {
+			t.Errorf("got %d calls, want %d", repo.calls, 0)
+		}
+	})
+}
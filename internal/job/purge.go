@@ -0,0 +1,40 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+// TrashPurger hard deletes recipes that have been in the trash longer than the retention window
+type TrashPurger struct {
+	recipeRepository repository.RecipeRepository
+	retention        time.Duration
+	interval         time.Duration
+}
+
+func NewTrashPurger(recipeRepository repository.RecipeRepository, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		recipeRepository: recipeRepository,
+		retention:        retention,
+		interval:         interval,
+	}
+}
+
+// Run blocks until the context is cancelled, purging once on start then on every interval
+func (p *TrashPurger) Run(ctx context.Context) {
+	every(ctx, p.interval, p.purge)
+}
+
+func (p *TrashPurger) purge(ctx context.Context) {
+	purged, err := p.recipeRepository.PurgeDeletedRecipes(ctx, p.retention)
+	if err != nil {
+		slog.ErrorContext(ctx, "error purging trash", "error", err)
+		return
+	}
+	if purged > 0 {
+		slog.InfoContext(ctx, "purged recipes from trash", "count", purged)
+	}
+}
@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// New is the logger of the app, json for the log collectors or text for reading in a terminal
+func New(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "text" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+type loggerCtxKey struct{}
+
+// requestLogger is shared by the middlewares of a request, so the attributes an inner one adds show up in the request log
+type requestLogger struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+func contextWithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerCtxKey{}, &requestLogger{logger: logger})
+}
+
+// FromContext is the logger of the request, with its request id, or the default logger outside of a request
+func FromContext(ctx context.Context) *slog.Logger {
+	l, ok := ctx.Value(loggerCtxKey{}).(*requestLogger)
+	if !ok {
+		return slog.Default()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.logger
+}
+
+// With adds attributes to every later log of the request, e.g. the user id once the caller is identified
+func With(ctx context.Context, args ...any) {
+	l, ok := ctx.Value(loggerCtxKey{}).(*requestLogger)
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logger = l.logger.With(args...)
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// captureLogs swaps the default logger for one writing json to the returned buffer
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	previous := slog.Default()
+	slog.SetDefault(New(buf, "json", slog.LevelDebug))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return buf
+}
+
+func decodeLogs(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var logs []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("error decoding log, %v", err)
+		}
+		logs = append(logs, entry)
+	}
+	return logs
+}
+
+func TestMiddleware(t *testing.T) {
+	newRouter := func() *chi.Mux {
+		r := chi.NewRouter()
+		r.Use(Middleware)
+		r.Get("/recipe/{id}", func(w http.ResponseWriter, r *http.Request) {
+			With(r.Context(), "user_id", 7)
+			FromContext(r.Context()).Info("error get recipe")
+			w.WriteHeader(http.StatusNotFound)
+		})
+		return r
+	}
+
+	t.Run("should log the request with its route, status and the attributes added by the handler", func(t *testing.T) {
+		buf := captureLogs(t)
+
+		req := httptest.NewRequest(http.MethodGet, "/recipe/1", nil)
+		req.Header.Set(HeaderRequestId, "req-1")
+		rec := httptest.NewRecorder()
+		newRouter().ServeHTTP(rec, req)
+
+		logs := decodeLogs(t, buf)
+		if len(logs) != 2 {
+			t.Fatalf("got %d logs, want %d", len(logs), 2)
+		}
+
+		for _, entry := range logs {
+			if entry["request_id"] != "req-1" || entry["user_id"] != float64(7) {
+				t.Errorf("got %v, want request_id req-1 and user_id 7", entry)
+			}
+		}
+
+		request := logs[1]
+		if request["msg"] != "request" || request["route"] != "/recipe/{id}" || request["status"] != float64(404) || request["method"] != http.MethodGet {
+			t.Errorf("got %v, want the request log", request)
+		}
+		if rec.Header().Get(HeaderRequestId) != "req-1" {
+			t.Errorf("got %s, want the request id echoed back", rec.Header().Get(HeaderRequestId))
+		}
+	})
+
+	t.Run("should generate a request id when the header has none", func(t *testing.T) {
+		buf := captureLogs(t)
+
+		rec := httptest.NewRecorder()
+		newRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/recipe/1", nil))
+
+		requestId := rec.Header().Get(HeaderRequestId)
+		if len(requestId) != 32 {
+			t.Errorf("got %q, want a generated request id", requestId)
+		}
+		if logs := decodeLogs(t, buf); logs[1]["request_id"] != requestId {
+			t.Errorf("got %v, want %s", logs[1]["request_id"], requestId)
+		}
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	t.Run("should fall back to the default logger outside of a request", func(t *testing.T) {
+		if FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()) != slog.Default() {
+			t.Errorf("got another logger, want the default one")
+		}
+	})
+}
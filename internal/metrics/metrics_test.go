@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/repository"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := New(nil, "")
+
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/recipe/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "id") == "0" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	for _, path := range []string{"/recipe/1", "/recipe/2", "/recipe/0", "/unknown"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	tests := []struct {
+		name   string
+		labels []string
+		want   float64
+	}{
+		{"should label the requests by route pattern", []string{http.MethodGet, "/recipe/{id}", "200"}, 2},
+		{"should label the requests by status", []string{http.MethodGet, "/recipe/{id}", "404"}, 1},
+		{"should label a request no route matched as unmatched", []string{http.MethodGet, "unmatched", "404"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := testutil.ToFloat64(m.requests.WithLabelValues(tt.labels...)); got != tt.want {
+				t.Errorf("got %v requests, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type mockRecipeRepository struct {
+	repository.RecipeRepository
+}
+
+func (m *mockRecipeRepository) GetRecipeById(ctx context.Context, viewer entity.Caller, id int64) (*entity.Recipe, error) {
+	if id == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &entity.Recipe{Id: id}, nil
+}
+
+func TestObserveOperation(t *testing.T) {
+	m := New(nil, "")
+	repo := repository.ObserveRecipeRepository(&mockRecipeRepository{}, m.ObserveOperation)
+
+	recipe, err := repo.GetRecipeById(context.Background(), entity.Caller{}, 1)
+	if err != nil || recipe.Id != 1 {
+		t.Fatalf("got %v, %v, want the recipe of the wrapped repository", recipe, err)
+	}
+	if _, err := repo.GetRecipeById(context.Background(), entity.Caller{}, 0); err != sql.ErrNoRows {
+		t.Fatalf("got %v, want %v", err, sql.ErrNoRows)
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, _ := io.ReadAll(rec.Result().Body)
+	for _, outcome := range []string{"ok", "error"} {
+		want := `recipe_api_repository_operation_duration_seconds_count{operation="GetRecipeById",outcome="` + outcome + `"} 1`
+		if !strings.Contains(string(body), want) {
+			t.Errorf("got %s, want %s", body, want)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := New(nil, "")
+	m.requests.WithLabelValues(http.MethodGet, "/recipe/{id}", "200").Inc()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body, _ := io.ReadAll(rec.Result().Body)
+	if !strings.Contains(string(body), `recipe_api_http_requests_total{method="GET",route="/recipe/{id}",status="200"} 1`) {
+		t.Errorf("got %s, want the request counter", body)
+	}
+}
@@ -0,0 +1,18 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// ObserveOperation is a repository.Observer timing every operation, labeled by the name of the method and its outcome
+func (m *Metrics) ObserveOperation(ctx context.Context, operation string) (context.Context, func(err error)) {
+	start := time.Now()
+	return ctx, func(err error) {
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
+		}
+		m.operationDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rhnauf/recipe-api/internal/helper"
+	"github.com/rhnauf/recipe-api/internal/logging"
+)
+
+// KeyFunc names the client a request counts against, e.g. its api key, user or ip
+type KeyFunc func(r *http.Request) string
+
+/*
+Limiter applies the policy of the route, keyed by method and chi route pattern such as POST /recipe,
+the routes without their own policy share the default bucket of the client
+*/
+type Limiter struct {
+	store         Store
+	key           KeyFunc
+	defaultPolicy Policy
+	routes        map[string]Policy
+}
+
+func NewLimiter(store Store, key KeyFunc, defaultPolicy Policy, routes map[string]Policy) *Limiter {
+	return &Limiter{store: store, key: key, defaultPolicy: defaultPolicy, routes: routes}
+}
+
+/*
+Middleware needs the route pattern, so it goes on the routes themselves with chi's Group or With,
+it answers 429 with Retry-After once the bucket is empty and sets the RateLimit headers on every
+response, a store that fails lets the request through rather than taking the api down with it
+*/
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + chi.RouteContext(r.Context()).RoutePattern()
+
+		name, policy := "default", l.defaultPolicy
+		if p, ok := l.routes[route]; ok {
+			name, policy = route, p
+		}
+
+		res, err := l.store.Take(r.Context(), name+"|"+l.key(r), policy)
+		if err != nil {
+			logging.FromContext(r.Context()).Error("error taking rate limit token", "error", err, "policy", name)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+		w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Period)))
+
+		if !res.Allowed {
+			retryAfter := ceilSeconds(res.RetryAfter)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			helper.HandleProblem(w, r, http.StatusTooManyRequests, fmt.Sprintf("rate limit exceeded, retry in %d seconds", retryAfter), nil)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	return Result{}, errors.New("store unavailable")
+}
+
+func newRouter(store Store) *chi.Mux {
+	limiter := NewLimiter(store, func(r *http.Request) string { return r.Header.Get("X-Client") },
+		Policy{Limit: 3, Period: time.Minute},
+		map[string]Policy{"POST /recipe": {Limit: 1, Period: time.Minute}},
+	)
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(limiter.Middleware)
+		r.Post("/recipe", ok)
+		r.Get("/recipe/{id}", ok)
+	})
+	return r
+}
+
+func send(r http.Handler, method, path, client string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-Client", client)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLimiter(t *testing.T) {
+	t.Run("should apply the stricter policy of the route", func(t *testing.T) {
+		r := newRouter(NewMemoryStore())
+
+		if rec := send(r, http.MethodPost, "/recipe", "a"); rec.Code != http.StatusOK {
+			t.Errorf("got %d, want %d", rec.Code, http.StatusOK)
+		}
+
+		rec := send(r, http.MethodPost, "/recipe", "a")
+		if rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("got %d, want %d", rec.Code, http.StatusTooManyRequests)
+		}
+		if rec.Header().Get("Retry-After") != "60" || rec.Header().Get("RateLimit-Remaining") != "0" || rec.Header().Get("RateLimit-Policy") != "1;w=60" {
+			t.Errorf("got %v, want Retry-After 60 and the RateLimit headers", rec.Header())
+		}
+	})
+
+	t.Run("should share the default bucket across the other routes", func(t *testing.T) {
+		r := newRouter(NewMemoryStore())
+
+		for _, path := range []string{"/recipe/1", "/recipe/2", "/recipe/3"} {
+			if rec := send(r, http.MethodGet, path, "a"); rec.Code != http.StatusOK {
+				t.Errorf("got %d, want %d", rec.Code, http.StatusOK)
+			}
+		}
+
+		rec := send(r, http.MethodGet, "/recipe/4", "a")
+		if rec.Code != http.StatusTooManyRequests {
+			t.Errorf("got %d, want %d", rec.Code, http.StatusTooManyRequests)
+		}
+		if rec.Header().Get("RateLimit-Limit") != "3" {
+			t.Errorf("got RateLimit-Limit %s, want 3", rec.Header().Get("RateLimit-Limit"))
+		}
+	})
+
+	t.Run("should keep a bucket per client", func(t *testing.T) {
+		r := newRouter(NewMemoryStore())
+
+		send(r, http.MethodPost, "/recipe", "a")
+		if rec := send(r, http.MethodPost, "/recipe", "b"); rec.Code != http.StatusOK {
+			t.Errorf("got %d, want %d", rec.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("should let the request through when the store fails", func(t *testing.T) {
+		r := newRouter(failingStore{})
+
+		if rec := send(r, http.MethodPost, "/recipe", "a"); rec.Code != http.StatusOK {
+			t.Errorf("got %d, want %d", rec.Code, http.StatusOK)
+		}
+	})
+}
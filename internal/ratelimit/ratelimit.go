@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy lets a client send Limit requests per Period, in a burst or spread out, the bucket refills continuously
+type Policy struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParsePolicy reads a policy written as limit/period, e.g. 30/1m
+func ParsePolicy(value string) (Policy, error) {
+	limit, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("%q is not limit/period", value)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(limit))
+	if err != nil || n < 1 {
+		return Policy{}, fmt.Errorf("limit of %q must be a whole number, 1 or more", value)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Policy{}, fmt.Errorf("period of %q must be a duration such as 1s or 1m", value)
+	}
+
+	return Policy{Limit: n, Period: d}, nil
+}
+
+func (p Policy) String() string {
+	return fmt.Sprintf("%d/%s", p.Limit, p.Period)
+}
+
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// Result is the state of a bucket after taking a token, RetryAfter is only set when the request is denied
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+/*
+Store keeps the buckets, the memory store works for a single instance, a store shared by every instance,
+e.g. on redis, keeps the limit when the app scales out, key already names both the client and the policy
+*/
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// Bucket is a token bucket, a shared store can keep its two fields and use Take to apply the same rules
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// NewBucket is a full bucket, a client never seen before gets the whole burst
+func NewBucket(policy Policy, now time.Time) *Bucket {
+	return &Bucket{Tokens: float64(policy.Limit), Updated: now}
+}
+
+// Take refills the bucket for the time since its last update and takes a token when there is one
+func (b *Bucket) Take(policy Policy, now time.Time) Result {
+	rate := policy.rate()
+	if elapsed := now.Sub(b.Updated).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(float64(policy.Limit), b.Tokens+elapsed*rate)
+	}
+	b.Updated = now
+
+	res := Result{Limit: policy.Limit}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.Tokens) / rate)
+	}
+
+	res.Remaining = int(b.Tokens)
+	res.Reset = seconds((float64(policy.Limit) - b.Tokens) / rate)
+	return res
+}
+
+// full tells whether the bucket refilled completely by now, such a bucket is the same as a new one
+func (b *Bucket) full(policy Policy, now time.Time) bool {
+	return b.Tokens+now.Sub(b.Updated).Seconds()*policy.rate() >= float64(policy.Limit)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Policy
+		wantErr bool
+	}{
+		{"30/1m", Policy{Limit: 30, Period: time.Minute}, false},
+		{" 5 / 10s ", Policy{Limit: 5, Period: 10 * time.Second}, false},
+		{"30", Policy{}, true},
+		{"0/1m", Policy{}, true},
+		{"30/0s", Policy{}, true},
+		{"many/1m", Policy{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePolicy(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%q got %v, %v, want %v, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBucket(t *testing.T) {
+	policy := Policy{Limit: 2, Period: 2 * time.Second}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	b := NewBucket(policy, now)
+
+	t.Run("should allow the whole burst", func(t *testing.T) {
+		for remaining := 1; remaining >= 0; remaining-- {
+			res := b.Take(policy, now)
+			if !res.Allowed || res.Remaining != remaining {
+				t.Errorf("got %+v, want allowed with %d remaining", res, remaining)
+			}
+		}
+	})
+
+	t.Run("should deny an empty bucket until a token refills", func(t *testing.T) {
+		res := b.Take(policy, now)
+		if res.Allowed || res.RetryAfter != time.Second || res.Reset != 2*time.Second {
+			t.Errorf("got %+v, want denied, retry after 1s and full after 2s", res)
+		}
+	})
+
+	t.Run("should refill with the time passed", func(t *testing.T) {
+		res := b.Take(policy, now.Add(time.Second))
+		if !res.Allowed || res.Remaining != 0 {
+			t.Errorf("got %+v, want allowed with 0 remaining", res)
+		}
+	})
+
+	t.Run("should not refill above the limit", func(t *testing.T) {
+		res := b.Take(policy, now.Add(time.Hour))
+		if !res.Allowed || res.Remaining != 1 {
+			t.Errorf("got %+v, want allowed with 1 remaining", res)
+		}
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	policy := Policy{Limit: 1, Period: time.Minute}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	t.Run("should keep a bucket per key", func(t *testing.T) {
+		if res, _ := s.Take(ctx, "a", policy); !res.Allowed {
+			t.Errorf("got denied, want the first request of a allowed")
+		}
+		if res, _ := s.Take(ctx, "a", policy); res.Allowed {
+			t.Errorf("got allowed, want the second request of a denied")
+		}
+		if res, _ := s.Take(ctx, "b", policy); !res.Allowed {
+			t.Errorf("got denied, want the first request of b allowed")
+		}
+	})
+
+	t.Run("should drop the buckets refilled completely", func(t *testing.T) {
+		now = now.Add(2 * time.Minute)
+		s.Take(ctx, "c", policy)
+
+		if len(s.buckets) != 1 {
+			t.Errorf("got %d buckets, want only the one of c", len(s.buckets))
+		}
+	})
+}
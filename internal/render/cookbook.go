@@ -0,0 +1,120 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+const (
+	PDFMediaType = "application/pdf"
+
+	tocEntriesPerPage = 30
+	lineHeight        = 6.0
+)
+
+/*
+Cookbook renders the recipes into a pdf with a cover, a table of contents and every recipe
+starting on its own page, the page numbers in the contents are filled in once the recipes are laid out
+*/
+func Cookbook(w io.Writer, title string, recipes []*entity.Recipe, now time.Time) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(title, true)
+	pdf.SetCreator("recipe-api", true)
+	pdf.SetCreationDate(now)
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+
+	// the core fonts only know cp1252, anything outside it is printed as a question mark
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	width, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	textWidth := width - left - right
+
+	pdf.SetFooterFunc(func() {
+		if pdf.PageNo() == 1 {
+			return
+		}
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 10, strconv.Itoa(pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	writeCover(pdf, tr, title, len(recipes), now)
+
+	links := make([]int, len(recipes))
+	for idx, recipe := range recipes {
+		if idx%tocEntriesPerPage == 0 {
+			pdf.AddPage()
+			if idx == 0 {
+				pdf.SetFont("Helvetica", "B", 18)
+				pdf.CellFormat(0, 12, "Contents", "", 1, "L", false, 0, "")
+				pdf.Ln(4)
+			}
+		}
+
+		links[idx] = pdf.AddLink()
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.CellFormat(textWidth-15, 7, tr(fmt.Sprintf("%d. %s", idx+1, recipe.Title)), "", 0, "L", false, links[idx], "")
+		pdf.CellFormat(15, 7, pageAlias(idx), "", 1, "R", false, links[idx], "")
+	}
+
+	for idx, recipe := range recipes {
+		pdf.AddPage()
+		pdf.SetLink(links[idx], -1, pdf.PageNo())
+		pdf.RegisterAlias(pageAlias(idx), strconv.Itoa(pdf.PageNo()))
+		writeRecipe(pdf, tr, textWidth, recipe)
+	}
+
+	return pdf.Output(w)
+}
+
+func writeCover(pdf *fpdf.Fpdf, tr func(string) string, title string, count int, now time.Time) {
+	pdf.AddPage()
+	pdf.SetY(100)
+	pdf.SetFont("Helvetica", "B", 28)
+	pdf.MultiCell(0, 12, tr(title), "", "C", false)
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "", 12)
+	pdf.CellFormat(0, 8, fmt.Sprintf("%d recipes", count), "", 1, "C", false, 0, "")
+	pdf.CellFormat(0, 8, now.Format("2 January 2006"), "", 1, "C", false, 0, "")
+}
+
+func writeRecipe(pdf *fpdf.Fpdf, tr func(string) string, textWidth float64, recipe *entity.Recipe) {
+	pdf.SetFont("Helvetica", "B", 20)
+	pdf.MultiCell(0, 10, tr(recipe.Title), "", "L", false)
+	pdf.Ln(2)
+
+	if recipe.Description != "" {
+		pdf.SetFont("Helvetica", "I", 11)
+		pdf.MultiCell(0, lineHeight, tr(recipe.Description), "", "L", false)
+		pdf.Ln(4)
+	}
+
+	steps := recipe.Steps()
+	if len(steps) == 0 {
+		return
+	}
+
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 8, "Steps", "B", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	pdf.SetFont("Helvetica", "", 11)
+	for idx, step := range steps {
+		x := pdf.GetX()
+		pdf.CellFormat(8, lineHeight, strconv.Itoa(idx+1)+".", "", 0, "R", false, 0, "")
+		pdf.SetX(x + 10)
+		pdf.MultiCell(textWidth-10, lineHeight, tr(step), "", "L", false)
+		pdf.Ln(1)
+	}
+}
+
+func pageAlias(idx int) string {
+	return "{p" + strconv.Itoa(idx) + "}"
+}
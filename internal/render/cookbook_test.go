@@ -0,0 +1,48 @@
+package render
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func TestCookbook(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("should render a cover, the contents and a page per recipe", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Cookbook(&buf, "Family Favourites", []*entity.Recipe{recipe, recipe}, now)
+		if err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		got := buf.String()
+		if !strings.HasPrefix(got, "%PDF-") {
+			t.Errorf("got %q, want a pdf", got[:10])
+		}
+		if !strings.Contains(got, "/Count 4") {
+			t.Errorf("want 4 pages, cover, contents and two recipes")
+		}
+	})
+
+	t.Run("should spread the contents over more pages", func(t *testing.T) {
+		recipes := make([]*entity.Recipe, tocEntriesPerPage+1)
+		for idx := range recipes {
+			recipes[idx] = &entity.Recipe{Title: "recipe " + strconv.Itoa(idx)}
+		}
+
+		var buf bytes.Buffer
+		if err := Cookbook(&buf, "Cookbook", recipes, now); err != nil {
+			t.Fatalf("got %v, want nil", err)
+		}
+
+		want := "/Count " + strconv.Itoa(1+2+len(recipes))
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("want %q in the pdf", want)
+		}
+	})
+}
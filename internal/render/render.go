@@ -0,0 +1,90 @@
+package render
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+const (
+	MarkdownMediaType = "text/markdown"
+	HTMLMediaType     = "text/html"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var markdownFuncs = texttemplate.FuncMap{
+	"md":  escapeMarkdown,
+	"inc": func(i int) int { return i + 1 },
+}
+
+var (
+	markdownTemplate = texttemplate.Must(texttemplate.New("recipe.md.tmpl").Funcs(markdownFuncs).ParseFS(templates, "templates/recipe.md.tmpl"))
+	htmlTemplate     = htmltemplate.Must(htmltemplate.ParseFS(templates, "templates/recipe.html.tmpl"))
+)
+
+/*
+view is what the templates print, recipes have no ingredients of their own yet so the page
+only has the title, the meta data and the numbered steps of the instruction
+*/
+type view struct {
+	Title       string
+	Description string
+	Meta        []meta
+	Steps       []string
+}
+
+type meta struct {
+	Label string
+	Value string
+}
+
+func newView(recipe *entity.Recipe) view {
+	v := view{
+		Title:       recipe.Title,
+		Description: strings.TrimSpace(recipe.Description),
+		Steps:       recipe.Steps(),
+	}
+	if recipe.Status != "" && recipe.Status != entity.StatusPublished {
+		v.Meta = append(v.Meta, meta{Label: "Status", Value: strings.ReplaceAll(recipe.Status, "_", " ")})
+	}
+	if !recipe.CreatedAt.IsZero() {
+		v.Meta = append(v.Meta, meta{Label: "Created", Value: recipe.CreatedAt.Format("2 January 2006")})
+	}
+	if !recipe.UpdatedAt.IsZero() && !recipe.UpdatedAt.Equal(recipe.CreatedAt) {
+		v.Meta = append(v.Meta, meta{Label: "Updated", Value: recipe.UpdatedAt.Format("2 January 2006")})
+	}
+	return v
+}
+
+func Markdown(recipe *entity.Recipe) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := markdownTemplate.Execute(&buf, newView(recipe)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// HTML renders a standalone page, the stylesheet is inlined and has print rules so the page prints cleanly
+func HTML(recipe *entity.Recipe) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, newView(recipe)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `#`, `\#`,
+	`[`, `\[`, `]`, `\]`, `<`, `\<`, `>`, `\>`, `|`, `\|`,
+)
+
+// escapeMarkdown keeps user text from being read as markup, a line break becomes a space
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(strings.Join(strings.Fields(s), " "))
+}
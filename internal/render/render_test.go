@@ -0,0 +1,56 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+var recipe = &entity.Recipe{
+	Title:       "nasi goreng *spesial*",
+	Description: "fried rice\nwith egg",
+	Instruction: "1. cook rice\n\n2. add <egg>\n",
+	Status:      entity.StatusPublished,
+	CreatedAt:   time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	UpdatedAt:   time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+}
+
+func TestMarkdown(t *testing.T) {
+	got, err := Markdown(recipe)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	want := "# nasi goreng \\*spesial\\*\n\n" +
+		"fried rice with egg\n\n" +
+		"- **Created:** 1 May 2024\n\n" +
+		"## Steps\n\n" +
+		"1. cook rice\n" +
+		"2. add \\<egg\\>\n"
+
+	if string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	got, err := HTML(recipe)
+	if err != nil {
+		t.Fatalf("got %v, want nil", err)
+	}
+
+	page := string(got)
+	for _, want := range []string{
+		"<title>nasi goreng *spesial*</title>",
+		"<li><strong>Created:</strong> 1 May 2024</li>",
+		"<li>cook rice</li>",
+		"<li>add &lt;egg&gt;</li>",
+		"@media print",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("got %q, want it to contain %q", page, want)
+		}
+	}
+}
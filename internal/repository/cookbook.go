@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"github.com/lib/pq"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+// GetRecipesByIds returns the recipes visible to the viewer in the order of ids, unknown or hidden ids are left out
+func (r *recipeRepository) GetRecipesByIds(ctx context.Context, viewer entity.Caller, ids []int64) ([]*entity.Recipe, error) {
+	ctx, cancel := r.timeouts.context(ctx, "GetRecipesByIds")
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, created_at, updated_at, title, COALESCE(slug, ''), COALESCE(description, ''), COALESCE(instruction, ''), status
+		FROM recipes
+		WHERE id = ANY($1) AND deleted_at IS NULL AND `+visibleTo("", 2, 3),
+		pq.Array(ids), viewer.Id, viewer.CanSeeDrafts())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byId := make(map[int64]*entity.Recipe)
+	for rows.Next() {
+		var recipe entity.Recipe
+		err := rows.Scan(
+			&recipe.Id,
+			&recipe.CreatedAt,
+			&recipe.UpdatedAt,
+			&recipe.Title,
+			&recipe.Slug,
+			&recipe.Description,
+			&recipe.Instruction,
+			&recipe.Status,
+		)
+		if err != nil {
+			return nil, err
+		}
+		byId[recipe.Id] = &recipe
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var recipes []*entity.Recipe
+	for _, id := range ids {
+		if recipe, ok := byId[id]; ok {
+			recipes = append(recipes, recipe)
+		}
+	}
+
+	return recipes, nil
+}
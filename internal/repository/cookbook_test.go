@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func TestGetRecipesByIds(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	viewer := entity.Caller{Id: 7}
+	ids := []int64{3, 1, 2}
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "SELECT id, created_at, updated_at, title, COALESCE(slug, ''), COALESCE(description, ''), COALESCE(instruction, ''), status FROM recipes WHERE id = ANY($1) AND deleted_at IS NULL AND ((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $2 OR $3)"
+
+	t.Run("should return the recipes in the order of ids", func(t *testing.T) {
+		now := time.Now()
+
+		rows := sqlmock.
+			NewRows([]string{"id", "created_at", "updated_at", "title", "slug", "description", "instruction", "status"}).
+			AddRow(1, now, now, "nasi goreng", "nasi-goreng", "", "", "published").
+			AddRow(3, now, now, "soto ayam", "soto-ayam", "", "", "published")
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs(pq.Array(ids), viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(rows)
+
+		got, err := repo.GetRecipesByIds(context.Background(), viewer, ids)
+
+		assertErr(t, err, nil)
+		if len(got) != 2 || got[0].Id != 3 || got[1].Id != 1 {
+			t.Errorf("got %v, want recipes 3 and 1", got)
+		}
+	})
+
+	t.Run("should return error getting recipes", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(pq.Array(ids), viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnError(sql.ErrConnDone)
+
+		got, err := repo.GetRecipesByIds(context.Background(), viewer, ids)
+
+		assertErr(t, err, sql.ErrConnDone)
+		if got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+}
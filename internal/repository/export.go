@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+// ExportRecipes walks every recipe visible to the viewer in id order, the rows are handed over one by one as they are read
+func (r *recipeRepository) ExportRecipes(ctx context.Context, viewer entity.Caller, fn func(*entity.Recipe) error) error {
+	ctx, cancel := r.timeouts.context(ctx, "ExportRecipes")
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, COALESCE(slug, ''), title, COALESCE(description, ''), COALESCE(instruction, ''), status, publish_at, created_at, updated_at
+		FROM recipes
+		WHERE deleted_at IS NULL AND `+visibleTo("", 1, 2)+`
+		ORDER BY id`, viewer.Id, viewer.CanSeeDrafts())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var recipe entity.Recipe
+		err := rows.Scan(
+			&recipe.Id,
+			&recipe.Slug,
+			&recipe.Title,
+			&recipe.Description,
+			&recipe.Instruction,
+			&recipe.Status,
+			&recipe.PublishAt,
+			&recipe.CreatedAt,
+			&recipe.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+		if err := fn(&recipe); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func TestExportRecipes(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	viewer := entity.Caller{Id: 7}
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "SELECT id, COALESCE(slug, ''), title, COALESCE(description, ''), COALESCE(instruction, ''), status, publish_at, created_at, updated_at FROM recipes WHERE deleted_at IS NULL AND ((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $1 OR $2) ORDER BY id"
+
+	t.Run("should hand over every row", func(t *testing.T) {
+		now := time.Now()
+
+		rows := sqlmock.
+			NewRows([]string{"id", "slug", "title", "description", "instruction", "status", "publish_at", "created_at", "updated_at"}).
+			AddRow(1, "nasi-goreng", "nasi goreng", "", "", "published", nil, now, now).
+			AddRow(2, "soto-ayam", "soto ayam", "", "", "draft", nil, now, now)
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs(viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(rows)
+
+		var got []int64
+		err := repo.ExportRecipes(context.Background(), viewer, func(recipe *entity.Recipe) error {
+			got = append(got, recipe.Id)
+			return nil
+		})
+
+		assertErr(t, err, nil)
+		if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+			t.Errorf("got %v, want [1 2]", got)
+		}
+	})
+
+	t.Run("should stop when the callback fails", func(t *testing.T) {
+		now := time.Now()
+
+		rows := sqlmock.
+			NewRows([]string{"id", "slug", "title", "description", "instruction", "status", "publish_at", "created_at", "updated_at"}).
+			AddRow(1, "nasi-goreng", "nasi goreng", "", "", "published", nil, now, now).
+			AddRow(2, "soto-ayam", "soto ayam", "", "", "draft", nil, now, now)
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs(viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(rows)
+
+		calls := 0
+		err := repo.ExportRecipes(context.Background(), viewer, func(recipe *entity.Recipe) error {
+			calls++
+			return sql.ErrConnDone
+		})
+
+		assertErr(t, err, sql.ErrConnDone)
+		if calls != 1 {
+			t.Errorf("got %d calls, want %d", calls, 1)
+		}
+	})
+
+	t.Run("should return error on export query", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnError(sql.ErrConnDone)
+
+		err := repo.ExportRecipes(context.Background(), viewer, func(recipe *entity.Recipe) error { return nil })
+
+		assertErr(t, err, sql.ErrConnDone)
+	})
+}
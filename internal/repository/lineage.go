@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+// forkTitleAttempts bounds the retries of a generated fork title taken by a concurrent fork in the meantime
+const forkTitleAttempts = 3
+
+/*
+the fork is always created as a draft owned by the viewer, when no title is given the source title
+is suffixed with the number following the highest fork suffix among the titles in use
+*/
+func (r *recipeRepository) ForkRecipe(ctx context.Context, viewer entity.Caller, id int64, title string) (int64, error) {
+	ctx, cancel := r.timeouts.context(ctx, "ForkRecipe")
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		var forkId int64
+		err := retryConflict(func() error {
+			return r.inTx(ctx, func(tx *sql.Tx) (err error) {
+				forkId, err = insertFork(ctx, tx, viewer, id, title)
+				return err
+			})
+		})
+		if isUniqueViolation(err, "uq_title") {
+			if title == "" && attempt < forkTitleAttempts {
+				continue
+			}
+			return 0, ErrDuplicateTitle
+		}
+		return forkId, err
+	}
+}
+
+func insertFork(ctx context.Context, q querier, viewer entity.Caller, id int64, title string) (int64, error) {
+	var forkId int64
+	var forkTitle string
+
+	err := q.QueryRowContext(ctx, `
+		WITH r AS (
+			INSERT INTO recipes(title, description, instruction, status, owner_id, forked_from_id)
+			SELECT
+				COALESCE(NULLIF($2, ''), LEFT(s.title, 80) || ' (fork ' || (
+					SELECT COALESCE(MAX(substring(f.title FROM ' \(fork ([0-9]+)\)$')::int), 0) + 1
+					FROM recipes f
+					WHERE f.deleted_at IS NULL AND f.title LIKE LEFT(s.title, 80) || ' (fork %)'
+				) || ')'),
+				description, instruction, 'draft', $3, id
+			FROM recipes s
+			WHERE id = $1 AND deleted_at IS NULL AND `+visibleTo("", 3, 4)+`
+			RETURNING id, title, description, instruction, owner_id
+		)
+		INSERT INTO recipe_revisions(recipe_id, revision, title, description, instruction, created_by)
+		SELECT id, 1, title, description, instruction, owner_id FROM r
+		RETURNING recipe_id, title`,
+		id,
+		title,
+		viewer.Id,
+		viewer.CanSeeDrafts(),
+	).Scan(&forkId, &forkTitle)
+	if err != nil {
+		return 0, err
+	}
+
+	return forkId, assignSlug(ctx, q, forkId, forkTitle)
+}
+
+// the whole tree is walked so a hidden recipe does not cut the lineage, only the hidden nodes are left out
+func (r *recipeRepository) GetRecipeAncestors(ctx context.Context, viewer entity.Caller, id int64) ([]*entity.LineageNode, error) {
+	ctx, cancel := r.timeouts.context(ctx, "GetRecipeAncestors")
+	defer cancel()
+
+	return r.queryLineage(ctx, `
+		WITH RECURSIVE ancestors AS (
+			SELECT p.id, p.title, p.owner_id, p.forked_from_id, p.status, p.publish_at, p.deleted_at, 1 AS depth
+			FROM recipes p JOIN recipes c ON c.forked_from_id = p.id
+			WHERE c.id = $1
+			UNION ALL
+			SELECT p.id, p.title, p.owner_id, p.forked_from_id, p.status, p.publish_at, p.deleted_at, a.depth + 1
+			FROM recipes p JOIN ancestors a ON a.forked_from_id = p.id
+		)
+		SELECT id, title, owner_id, forked_from_id, depth FROM ancestors
+		WHERE deleted_at IS NULL AND `+visibleTo("", 2, 3)+`
+		ORDER BY depth`, id, viewer)
+}
+
+func (r *recipeRepository) GetRecipeForks(ctx context.Context, viewer entity.Caller, id int64) ([]*entity.LineageNode, error) {
+	ctx, cancel := r.timeouts.context(ctx, "GetRecipeForks")
+	defer cancel()
+
+	return r.queryLineage(ctx, `
+		WITH RECURSIVE forks AS (
+			SELECT id, title, owner_id, forked_from_id, status, publish_at, deleted_at, 1 AS depth
+			FROM recipes WHERE forked_from_id = $1
+			UNION ALL
+			SELECT c.id, c.title, c.owner_id, c.forked_from_id, c.status, c.publish_at, c.deleted_at, f.depth + 1
+			FROM recipes c JOIN forks f ON c.forked_from_id = f.id
+		)
+		SELECT id, title, owner_id, forked_from_id, depth FROM forks
+		WHERE deleted_at IS NULL AND `+visibleTo("", 2, 3)+`
+		ORDER BY depth, id`, id, viewer)
+}
+
+func (r *recipeRepository) queryLineage(ctx context.Context, query string, id int64, viewer entity.Caller) ([]*entity.LineageNode, error) {
+	var nodes []*entity.LineageNode
+
+	rows, err := r.db.QueryContext(ctx, query, id, viewer.Id, viewer.CanSeeDrafts())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var node entity.LineageNode
+		if err := rows.Scan(&node.Id, &node.Title, &node.OwnerId, &node.ForkedFromId, &node.Depth); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, &node)
+	}
+
+	return nodes, rows.Err()
+}
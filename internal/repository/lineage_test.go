@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func assertLineageEqual(t *testing.T, got, want []*entity.LineageNode) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestForkRecipe(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var id int64 = 1
+	viewer := entity.Caller{Id: 7}
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "WITH r AS ( INSERT INTO recipes(title, description, instruction, status, owner_id, forked_from_id) SELECT COALESCE(NULLIF($2, ''), LEFT(s.title, 80) || ' (fork ' || ( SELECT COALESCE(MAX(substring(f.title FROM ' \\(fork ([0-9]+)\\)$')::int), 0) + 1 FROM recipes f WHERE f.deleted_at IS NULL AND f.title LIKE LEFT(s.title, 80) || ' (fork %)' ) || ')'), description, instruction, 'draft', $3, id FROM recipes s WHERE id = $1 AND deleted_at IS NULL AND ((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $3 OR $4) RETURNING id, title, description, instruction, owner_id ) INSERT INTO recipe_revisions(recipe_id, revision, title, description, instruction, created_by) SELECT id, 1, title, description, instruction, owner_id FROM r RETURNING recipe_id, title"
+
+	t.Run("should return fork id on fork query", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, "", viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(sqlmock.NewRows([]string{"recipe_id", "title"}).AddRow(2, "nasi goreng (fork 1)"))
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng-fork-1").
+			WillReturnRows(slugRows())
+		mock.
+			ExpectExec(slugAssignQry).
+			WithArgs(2, "nasi-goreng-fork-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		got, err := repo.ForkRecipe(context.Background(), viewer, id, "")
+
+		assertErr(t, err, nil)
+		if got != 2 {
+			t.Errorf("got %d, want %d", got, 2)
+		}
+	})
+
+	t.Run("should retry the generated title taken by a concurrent fork", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, "", viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_title"})
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, "", viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(sqlmock.NewRows([]string{"recipe_id", "title"}).AddRow(3, "nasi goreng (fork 2)"))
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng-fork-2").
+			WillReturnRows(slugRows())
+		mock.
+			ExpectExec(slugAssignQry).
+			WithArgs(3, "nasi-goreng-fork-2").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		got, err := repo.ForkRecipe(context.Background(), viewer, id, "")
+
+		assertErr(t, err, nil)
+		if got != 3 {
+			t.Errorf("got %d, want %d", got, 3)
+		}
+	})
+
+	t.Run("should return error duplicate title when the given title is taken", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, "my fork", viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_title"})
+		mock.ExpectRollback()
+
+		_, err := repo.ForkRecipe(context.Background(), viewer, id, "my fork")
+
+		assertErr(t, err, ErrDuplicateTitle)
+	})
+
+	t.Run("should return error not found when source recipe does not exist", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, "my fork", viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(sqlmock.NewRows([]string{"recipe_id", "title"}))
+		mock.ExpectRollback()
+
+		got, err := repo.ForkRecipe(context.Background(), viewer, id, "my fork")
+
+		assertErr(t, err, sql.ErrNoRows)
+		if got != 0 {
+			t.Errorf("got %d, want %d", got, 0)
+		}
+	})
+}
+
+func TestGetRecipeAncestors(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var id int64 = 3
+
+	viewer := entity.Caller{Id: 7}
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "WITH RECURSIVE ancestors AS ( SELECT p.id, p.title, p.owner_id, p.forked_from_id, p.status, p.publish_at, p.deleted_at, 1 AS depth FROM recipes p JOIN recipes c ON c.forked_from_id = p.id WHERE c.id = $1 UNION ALL SELECT p.id, p.title, p.owner_id, p.forked_from_id, p.status, p.publish_at, p.deleted_at, a.depth + 1 FROM recipes p JOIN ancestors a ON a.forked_from_id = p.id ) SELECT id, title, owner_id, forked_from_id, depth FROM ancestors WHERE deleted_at IS NULL AND ((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $2 OR $3) ORDER BY depth"
+
+	t.Run("should return ancestors ordered by depth", func(t *testing.T) {
+		rows := sqlmock.
+			NewRows([]string{"id", "title", "owner_id", "forked_from_id", "depth"}).
+			AddRow(2, "nasi goreng (fork 1)", 7, 1, 1).
+			AddRow(1, "nasi goreng", nil, nil, 2)
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(rows)
+
+		got, err := repo.GetRecipeAncestors(context.Background(), viewer, id)
+
+		var ownerId, parentId int64 = 7, 1
+		want := []*entity.LineageNode{
+			{Id: 2, Title: "nasi goreng (fork 1)", OwnerId: &ownerId, ForkedFromId: &parentId, Depth: 1},
+			{Id: 1, Title: "nasi goreng", Depth: 2},
+		}
+
+		assertErr(t, err, nil)
+		assertLineageEqual(t, got, want)
+	})
+
+	t.Run("should return error getting ancestors", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnError(sql.ErrConnDone)
+
+		got, err := repo.GetRecipeAncestors(context.Background(), viewer, id)
+
+		assertErr(t, err, sql.ErrConnDone)
+		assertLineageEqual(t, got, nil)
+	})
+}
+
+func TestGetRecipeForks(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var id int64 = 1
+
+	viewer := entity.Caller{Id: 7}
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "WITH RECURSIVE forks AS ( SELECT id, title, owner_id, forked_from_id, status, publish_at, deleted_at, 1 AS depth FROM recipes WHERE forked_from_id = $1 UNION ALL SELECT c.id, c.title, c.owner_id, c.forked_from_id, c.status, c.publish_at, c.deleted_at, f.depth + 1 FROM recipes c JOIN forks f ON c.forked_from_id = f.id ) SELECT id, title, owner_id, forked_from_id, depth FROM forks WHERE deleted_at IS NULL AND ((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $2 OR $3) ORDER BY depth, id"
+
+	t.Run("should return forks of the recipe", func(t *testing.T) {
+		rows := sqlmock.
+			NewRows([]string{"id", "title", "owner_id", "forked_from_id", "depth"}).
+			AddRow(2, "nasi goreng (fork 1)", 7, 1, 1)
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(rows)
+
+		got, err := repo.GetRecipeForks(context.Background(), viewer, id)
+
+		var ownerId, parentId int64 = 7, 1
+		want := []*entity.LineageNode{
+			{Id: 2, Title: "nasi goreng (fork 1)", OwnerId: &ownerId, ForkedFromId: &parentId, Depth: 1},
+		}
+
+		assertErr(t, err, nil)
+		assertLineageEqual(t, got, want)
+	})
+
+	t.Run("should return error scanning forks", func(t *testing.T) {
+		rows := sqlmock.
+			NewRows([]string{"id", "title", "owner_id", "forked_from_id", "depth"}).
+			AddRow("invalid", "nasi goreng (fork 1)", 7, 1, 1)
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(rows)
+
+		got, err := repo.GetRecipeForks(context.Background(), viewer, id)
+
+		if err == nil {
+			t.Errorf("got nil, want scan error")
+		}
+		assertLineageEqual(t, got, nil)
+	})
+}
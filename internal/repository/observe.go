@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+/*
+Observer is called when an operation of a repository starts, named after its method, the context it
+returns is passed down to the operation and the returned function is called with the error it ended with
+*/
+type Observer func(ctx context.Context, operation string) (context.Context, func(err error))
+
+// observedRecipeRepository runs the observers around every operation, e.g. to time or trace them
+type observedRecipeRepository struct {
+	next      RecipeRepository
+	observers []Observer
+}
+
+func ObserveRecipeRepository(next RecipeRepository, observers ...Observer) RecipeRepository {
+	return &observedRecipeRepository{next: next, observers: observers}
+}
+
+// the observers end in reverse order, so the first one wraps the others like a middleware
+func (r *observedRecipeRepository) observe(ctx context.Context, operation string) (context.Context, func(err error)) {
+	ends := make([]func(err error), len(r.observers))
+	for idx, observer := range r.observers {
+		ctx, ends[idx] = observer(ctx, operation)
+	}
+
+	return ctx, func(err error) {
+		for idx := len(ends) - 1; idx >= 0; idx-- {
+			ends[idx](err)
+		}
+	}
+}
+
+func (r *observedRecipeRepository) InsertRecipe(ctx context.Context, recipe entity.Recipe) (err error) {
+	ctx, end := r.observe(ctx, "InsertRecipe")
+	defer func() { end(err) }()
+	return r.next.InsertRecipe(ctx, recipe)
+}
+
+func (r *observedRecipeRepository) UpdateRecipe(ctx context.Context, recipe entity.Recipe, fromStatus string, editedBy *int64) (_ int64, err error) {
+	ctx, end := r.observe(ctx, "UpdateRecipe")
+	defer func() { end(err) }()
+	return r.next.UpdateRecipe(ctx, recipe, fromStatus, editedBy)
+}
+
+func (r *observedRecipeRepository) GetRecipeById(ctx context.Context, viewer entity.Caller, id int64) (_ *entity.Recipe, err error) {
+	ctx, end := r.observe(ctx, "GetRecipeById")
+	defer func() { end(err) }()
+	return r.next.GetRecipeById(ctx, viewer, id)
+}
+
+func (r *observedRecipeRepository) GetRecipeBySlug(ctx context.Context, viewer entity.Caller, slug string) (_ *entity.Recipe, err error) {
+	ctx, end := r.observe(ctx, "GetRecipeBySlug")
+	defer func() { end(err) }()
+	return r.next.GetRecipeBySlug(ctx, viewer, slug)
+}
+
+func (r *observedRecipeRepository) GetRecipeSlugRedirect(ctx context.Context, viewer entity.Caller, slug string) (_ string, err error) {
+	ctx, end := r.observe(ctx, "GetRecipeSlugRedirect")
+	defer func() { end(err) }()
+	return r.next.GetRecipeSlugRedirect(ctx, viewer, slug)
+}
+
+func (r *observedRecipeRepository) DeleteRecipeById(ctx context.Context, id, version int64) (err error) {
+	ctx, end := r.observe(ctx, "DeleteRecipeById")
+	defer func() { end(err) }()
+	return r.next.DeleteRecipeById(ctx, id, version)
+}
+
+func (r *observedRecipeRepository) GetListRecipe(ctx context.Context, viewer entity.Caller, limit, offset int64) (_ []*entity.Recipe, err error) {
+	ctx, end := r.observe(ctx, "GetListRecipe")
+	defer func() { end(err) }()
+	return r.next.GetListRecipe(ctx, viewer, limit, offset)
+}
+
+func (r *observedRecipeRepository) ForkRecipe(ctx context.Context, viewer entity.Caller, id int64, title string) (_ int64, err error) {
+	ctx, end := r.observe(ctx, "ForkRecipe")
+	defer func() { end(err) }()
+	return r.next.ForkRecipe(ctx, viewer, id, title)
+}
+
+func (r *observedRecipeRepository) GetRecipeAncestors(ctx context.Context, viewer entity.Caller, id int64) (_ []*entity.LineageNode, err error) {
+	ctx, end := r.observe(ctx, "GetRecipeAncestors")
+	defer func() { end(err) }()
+	return r.next.GetRecipeAncestors(ctx, viewer, id)
+}
+
+func (r *observedRecipeRepository) GetRecipeForks(ctx context.Context, viewer entity.Caller, id int64) (_ []*entity.LineageNode, err error) {
+	ctx, end := r.observe(ctx, "GetRecipeForks")
+	defer func() { end(err) }()
+	return r.next.GetRecipeForks(ctx, viewer, id)
+}
+
+func (r *observedRecipeRepository) GetRecipeRevisions(ctx context.Context, viewer entity.Caller, recipeId int64) (_ []*entity.Revision, err error) {
+	ctx, end := r.observe(ctx, "GetRecipeRevisions")
+	defer func() { end(err) }()
+	return r.next.GetRecipeRevisions(ctx, viewer, recipeId)
+}
+
+func (r *observedRecipeRepository) GetRecipeRevision(ctx context.Context, viewer entity.Caller, recipeId, revision int64) (_ *entity.Revision, err error) {
+	ctx, end := r.observe(ctx, "GetRecipeRevision")
+	defer func() { end(err) }()
+	return r.next.GetRecipeRevision(ctx, viewer, recipeId, revision)
+}
+
+func (r *observedRecipeRepository) RestoreRecipeRevision(ctx context.Context, restoredBy entity.Caller, recipeId, revision int64) (_ int64, err error) {
+	ctx, end := r.observe(ctx, "RestoreRecipeRevision")
+	defer func() { end(err) }()
+	return r.next.RestoreRecipeRevision(ctx, restoredBy, recipeId, revision)
+}
+
+func (r *observedRecipeRepository) GetListDeletedRecipe(ctx context.Context, ownerId *int64, limit, offset int64) (_ []*entity.Recipe, err error) {
+	ctx, end := r.observe(ctx, "GetListDeletedRecipe")
+	defer func() { end(err) }()
+	return r.next.GetListDeletedRecipe(ctx, ownerId, limit, offset)
+}
+
+func (r *observedRecipeRepository) RestoreDeletedRecipe(ctx context.Context, id int64, ownerId *int64) (err error) {
+	ctx, end := r.observe(ctx, "RestoreDeletedRecipe")
+	defer func() { end(err) }()
+	return r.next.RestoreDeletedRecipe(ctx, id, ownerId)
+}
+
+func (r *observedRecipeRepository) PurgeDeletedRecipes(ctx context.Context, retention time.Duration) (_ int64, err error) {
+	ctx, end := r.observe(ctx, "PurgeDeletedRecipes")
+	defer func() { end(err) }()
+	return r.next.PurgeDeletedRecipes(ctx, retention)
+}
+
+func (r *observedRecipeRepository) TransitionRecipeStatus(ctx context.Context, id int64, from, to string, changedBy *int64) (_ int64, err error) {
+	ctx, end := r.observe(ctx, "TransitionRecipeStatus")
+	defer func() { end(err) }()
+	return r.next.TransitionRecipeStatus(ctx, id, from, to, changedBy)
+}
+
+func (r *observedRecipeRepository) GetRecipeStatusTransitions(ctx context.Context, viewer entity.Caller, id int64) (_ []*entity.StatusTransition, err error) {
+	ctx, end := r.observe(ctx, "GetRecipeStatusTransitions")
+	defer func() { end(err) }()
+	return r.next.GetRecipeStatusTransitions(ctx, viewer, id)
+}
+
+func (r *observedRecipeRepository) PublishDueRecipes(ctx context.Context, limit int) (_ int64, err error) {
+	ctx, end := r.observe(ctx, "PublishDueRecipes")
+	defer func() { end(err) }()
+	return r.next.PublishDueRecipes(ctx, limit)
+}
+
+func (r *observedRecipeRepository) ExportRecipes(ctx context.Context, viewer entity.Caller, fn func(*entity.Recipe) error) (err error) {
+	ctx, end := r.observe(ctx, "ExportRecipes")
+	defer func() { end(err) }()
+	return r.next.ExportRecipes(ctx, viewer, fn)
+}
+
+func (r *observedRecipeRepository) GetRecipesByIds(ctx context.Context, viewer entity.Caller, ids []int64) (_ []*entity.Recipe, err error) {
+	ctx, end := r.observe(ctx, "GetRecipesByIds")
+	defer func() { end(err) }()
+	return r.next.GetRecipesByIds(ctx, viewer, ids)
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type observedCtxKey struct{}
+
+type stubRecipeRepository struct {
+	RecipeRepository
+	observedBy interface{}
+}
+
+func (s *stubRecipeRepository) DeleteRecipeById(ctx context.Context, id, version int64) error {
+	s.observedBy = ctx.Value(observedCtxKey{})
+	return sql.ErrConnDone
+}
+
+func TestObserveRecipeRepository(t *testing.T) {
+	var calls []string
+	var ended error
+	observer := func(name string) Observer {
+		return func(ctx context.Context, operation string) (context.Context, func(err error)) {
+			calls = append(calls, name+" start "+operation)
+			return context.WithValue(ctx, observedCtxKey{}, name), func(err error) {
+				calls = append(calls, name+" end "+operation)
+				ended = err
+			}
+		}
+	}
+
+	stub := &stubRecipeRepository{}
+	repo := ObserveRecipeRepository(stub, observer("outer"), observer("inner"))
+
+	err := repo.DeleteRecipeById(context.Background(), 1, 2)
+
+	t.Run("should end the observers in reverse order", func(t *testing.T) {
+		want := []string{"outer start DeleteRecipeById", "inner start DeleteRecipeById", "inner end DeleteRecipeById", "outer end DeleteRecipeById"}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("got %v, want %v", calls, want)
+		}
+	})
+
+	t.Run("should pass the context of the observers down to the operation", func(t *testing.T) {
+		if stub.observedBy != "inner" {
+			t.Errorf("got %v, want %v", stub.observedBy, "inner")
+		}
+	})
+
+	t.Run("should pass the error of the operation to the observers and the caller", func(t *testing.T) {
+		if ended != sql.ErrConnDone || err != sql.ErrConnDone {
+			t.Errorf("got %v and %v, want %v", ended, err, sql.ErrConnDone)
+		}
+	})
+}
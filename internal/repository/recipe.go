@@ -1,59 +1,206 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"github.com/lib/pq"
 	"github.com/rhnauf/recipe-api/internal/entity"
+	"time"
 )
 
+var (
+	ErrVersionConflict = entity.NewError(entity.ErrPrecondition, "recipe has been modified")
+	ErrStatusConflict  = entity.NewError(entity.ErrConflict, "recipe status has been changed")
+	ErrDuplicateTitle  = entity.NewError(entity.ErrConflict, "recipe title already exists")
+)
+
+// conflictAttempts bounds the retries of a revision number or slug taken by a concurrent write
+const conflictAttempts = 3
+
 type recipeRepository struct {
-	db *sql.DB
+	db       *sql.DB
+	timeouts Timeouts
 }
 
+/*
+every read takes the viewer and only returns the recipes visible to it, anonymous viewers
+see published recipes once their publish_at has passed, authors also see their own and the editorial team sees everything,
+the queries are cancelled with the context and bounded by the timeout of the operation
+*/
 type RecipeRepository interface {
-	InsertRecipe(recipe entity.Recipe) error
-	UpdateRecipe(recipe entity.Recipe) error
-	GetRecipeById(id int64) (*entity.Recipe, error)
-	DeleteRecipeById(id int64) error
-	GetListRecipe(limit, offset int64) ([]*entity.Recipe, error)
+	InsertRecipe(ctx context.Context, recipe entity.Recipe) error
+	UpdateRecipe(ctx context.Context, recipe entity.Recipe, fromStatus string, editedBy *int64) (int64, error)
+	GetRecipeById(ctx context.Context, viewer entity.Caller, id int64) (*entity.Recipe, error)
+	GetRecipeBySlug(ctx context.Context, viewer entity.Caller, slug string) (*entity.Recipe, error)
+	GetRecipeSlugRedirect(ctx context.Context, viewer entity.Caller, slug string) (string, error)
+	DeleteRecipeById(ctx context.Context, id, version int64) error
+	GetListRecipe(ctx context.Context, viewer entity.Caller, limit, offset int64) ([]*entity.Recipe, error)
+	ForkRecipe(ctx context.Context, viewer entity.Caller, id int64, title string) (int64, error)
+	GetRecipeAncestors(ctx context.Context, viewer entity.Caller, id int64) ([]*entity.LineageNode, error)
+	GetRecipeForks(ctx context.Context, viewer entity.Caller, id int64) ([]*entity.LineageNode, error)
+	GetRecipeRevisions(ctx context.Context, viewer entity.Caller, recipeId int64) ([]*entity.Revision, error)
+	GetRecipeRevision(ctx context.Context, viewer entity.Caller, recipeId, revision int64) (*entity.Revision, error)
+	RestoreRecipeRevision(ctx context.Context, restoredBy entity.Caller, recipeId, revision int64) (int64, error)
+	GetListDeletedRecipe(ctx context.Context, ownerId *int64, limit, offset int64) ([]*entity.Recipe, error)
+	RestoreDeletedRecipe(ctx context.Context, id int64, ownerId *int64) error
+	PurgeDeletedRecipes(ctx context.Context, retention time.Duration) (int64, error)
+	TransitionRecipeStatus(ctx context.Context, id int64, from, to string, changedBy *int64) (int64, error)
+	GetRecipeStatusTransitions(ctx context.Context, viewer entity.Caller, id int64) ([]*entity.StatusTransition, error)
+	PublishDueRecipes(ctx context.Context, limit int) (int64, error)
+	ExportRecipes(ctx context.Context, viewer entity.Caller, fn func(*entity.Recipe) error) error
+	GetRecipesByIds(ctx context.Context, viewer entity.Caller, ids []int64) ([]*entity.Recipe, error)
 }
 
-func NewRecipeRepository(db *sql.DB) *recipeRepository {
-	return &recipeRepository{db: db}
+// querier is what *sql.DB and *sql.Tx have in common, so a statement runs on its own or within a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
 
-func (r *recipeRepository) InsertRecipe(recipe entity.Recipe) error {
-	_, err := r.db.Exec(`
-		INSERT INTO recipes(title, description, instruction, publish)
-		VALUES ($1, $2, $3, $4)`,
-		recipe.Title,
-		recipe.Description,
-		recipe.Instruction,
-		*recipe.Publish,
-	)
+func NewRecipeRepository(db *sql.DB, timeouts Timeouts) *recipeRepository {
+	return &recipeRepository{db: db, timeouts: timeouts}
+}
+
+/*
+every insert and update also stores the new content as a revision within the same statement,
+the slug is derived from the title within the same transaction, ErrDuplicateTitle is returned
+when another recipe outside the trash already has the title. A recipe is inserted as draft and
+then walks the workflow to recipe.Status, every step audited as made by its owner
+*/
+func (r *recipeRepository) InsertRecipe(ctx context.Context, recipe entity.Recipe) error {
+	ctx, cancel := r.timeouts.context(ctx, "InsertRecipe")
+	defer cancel()
+
+	err := retryConflict(func() error {
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			var id int64
 
+			err := tx.QueryRowContext(ctx, `
+				WITH r AS (
+					INSERT INTO recipes(title, description, instruction, status, owner_id, publish_at)
+					VALUES ($1, $2, $3, $4, $5, $6)
+					RETURNING id, title, description, instruction, owner_id
+				)
+				INSERT INTO recipe_revisions(recipe_id, revision, title, description, instruction, created_by)
+				SELECT id, 1, title, description, instruction, owner_id FROM r
+				RETURNING recipe_id`,
+				recipe.Title,
+				recipe.Description,
+				recipe.Instruction,
+				entity.StatusDraft,
+				recipe.OwnerId,
+				recipe.PublishAt,
+			).Scan(&id)
+			if err != nil {
+				return err
+			}
+
+			from := entity.StatusDraft
+			for _, to := range entity.TransitionPath(from, recipe.Status) {
+				if _, err := transitionStatus(ctx, tx, id, from, to, recipe.OwnerId); err != nil {
+					return err
+				}
+				from = to
+			}
+
+			return assignSlug(ctx, tx, id, recipe.Title)
+		})
+	})
+	if isUniqueViolation(err, "uq_title") {
+		return ErrDuplicateTitle
+	}
 	return err
 }
 
-func (r *recipeRepository) UpdateRecipe(recipe entity.Recipe) error {
-	_, err := r.db.Exec(`
-		UPDATE recipes
-		SET title = $1, description = $2, instruction = $3, publish = $4
-		WHERE id = $5`,
-		recipe.Title,
-		recipe.Description,
-		recipe.Instruction,
-		*recipe.Publish,
-		recipe.Id,
-	)
+/*
+only the content is updated unless recipe.Status is set, the status then walks the workflow from fromStatus within
+the same transaction so the edit is rolled back with ErrStatusConflict when the status changed meanwhile,
+recipe.Version is the version the caller based its edit on, zero skips the check,
+ErrVersionConflict is returned when the recipe has been changed in the meantime
+*/
+func (r *recipeRepository) UpdateRecipe(ctx context.Context, recipe entity.Recipe, fromStatus string, editedBy *int64) (int64, error) {
+	ctx, cancel := r.timeouts.context(ctx, "UpdateRecipe")
+	defer cancel()
 
-	return err
+	var version int64
+
+	err := retryConflict(func() error {
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			err := tx.QueryRowContext(ctx, `
+				WITH r AS (
+					UPDATE recipes
+					SET title = $1, description = $2, instruction = $3, publish_at = $7, version = version + 1, updated_at = now()
+					WHERE id = $4 AND deleted_at IS NULL AND ($6 = 0 OR version = $6)
+					RETURNING id, title, description, instruction, version
+				)
+				INSERT INTO recipe_revisions(recipe_id, revision, title, description, instruction, created_by)
+				SELECT id, (SELECT COALESCE(MAX(revision), 0) + 1 FROM recipe_revisions WHERE recipe_id = $4),
+					title, description, instruction, $5
+				FROM r
+				RETURNING (SELECT version FROM r)`,
+				recipe.Title,
+				recipe.Description,
+				recipe.Instruction,
+				recipe.Id,
+				editedBy,
+				recipe.Version,
+				recipe.PublishAt,
+			).Scan(&version)
+			if err != nil {
+				return err
+			}
+
+			from := fromStatus
+			for _, to := range entity.TransitionPath(from, recipe.Status) {
+				if version, err = transitionStatus(ctx, tx, recipe.Id, from, to, editedBy); err != nil {
+					return err
+				}
+				from = to
+			}
+
+			return assignSlug(ctx, tx, recipe.Id, recipe.Title)
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) && recipe.Version != 0 {
+		return 0, r.versionConflict(ctx, recipe.Id)
+	}
+	if isUniqueViolation(err, "uq_title") {
+		return 0, ErrDuplicateTitle
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
 }
 
-func (r *recipeRepository) GetRecipeById(id int64) (*entity.Recipe, error) {
+func (r *recipeRepository) GetRecipeById(ctx context.Context, viewer entity.Caller, id int64) (*entity.Recipe, error) {
+	ctx, cancel := r.timeouts.context(ctx, "GetRecipeById")
+	defer cancel()
+
 	var recipe entity.Recipe
 
-	err := r.db.QueryRow("SELECT * FROM recipes WHERE id = $1", id).
-		Scan(&recipe.Id, &recipe.CreatedAt, &recipe.Title, &recipe.Description, &recipe.Instruction, &recipe.Publish)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, created_at, updated_at, title, COALESCE(slug, ''), description, instruction, status, owner_id, forked_from_id, version, publish_at
+		FROM recipes
+		WHERE id = $1 AND deleted_at IS NULL AND `+visibleTo("", 2, 3),
+		id, viewer.Id, viewer.CanSeeDrafts()).
+		Scan(
+			&recipe.Id,
+			&recipe.CreatedAt,
+			&recipe.UpdatedAt,
+			&recipe.Title,
+			&recipe.Slug,
+			&recipe.Description,
+			&recipe.Instruction,
+			&recipe.Status,
+			&recipe.OwnerId,
+			&recipe.ForkedFromId,
+			&recipe.Version,
+			&recipe.PublishAt,
+		)
 	if err != nil {
 		return nil, err
 	}
@@ -61,35 +208,102 @@ func (r *recipeRepository) GetRecipeById(id int64) (*entity.Recipe, error) {
 	return &recipe, nil
 }
 
-func (r *recipeRepository) DeleteRecipeById(id int64) error {
-	_, err := r.db.Exec("DELETE FROM recipes WHERE id = $1", id)
+// deleting only moves the recipe to the trash, it is hard deleted by PurgeDeletedRecipes after the retention window
+func (r *recipeRepository) DeleteRecipeById(ctx context.Context, id, version int64) error {
+	ctx, cancel := r.timeouts.context(ctx, "DeleteRecipeById")
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE recipes
+		SET deleted_at = now(), version = version + 1, updated_at = now()
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)`,
+		id,
+		version,
+	)
 	if err != nil {
 		return err
 	}
 
-	/*
-		could get rows affected from result to get specific err no rows, if rows affected == 0
-		for simplicity just return success regardless the rows affected
-	*/
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		if version == 0 {
+			return sql.ErrNoRows
+		}
+		return r.versionConflict(ctx, id)
+	}
 
 	return nil
 }
 
-func (r *recipeRepository) GetListRecipe(limit, offset int64) ([]*entity.Recipe, error) {
+func (r *recipeRepository) GetListRecipe(ctx context.Context, viewer entity.Caller, limit, offset int64) ([]*entity.Recipe, error) {
+	ctx, cancel := r.timeouts.context(ctx, "GetListRecipe")
+	defer cancel()
+
 	var recipes []*entity.Recipe
 
-	rows, err := r.db.Query("SELECT id, title FROM recipes LIMIT $1 OFFSET $2", limit, offset)
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, title, COALESCE(slug, ''), version, updated_at FROM recipes
+		WHERE deleted_at IS NULL AND `+visibleTo("", 3, 4)+`
+		LIMIT $1 OFFSET $2`, limit, offset, viewer.Id, viewer.CanSeeDrafts())
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var recipe entity.Recipe
-		if err := rows.Scan(&recipe.Id, &recipe.Title); err != nil {
-			continue
+		if err := rows.Scan(&recipe.Id, &recipe.Title, &recipe.Slug, &recipe.Version, &recipe.UpdatedAt); err != nil {
+			return nil, err
 		}
 		recipes = append(recipes, &recipe)
 	}
 
-	return recipes, nil
+	return recipes, rows.Err()
+}
+
+// versionConflict tells whether a conditional write missed because the recipe is gone or because it is stale
+func (r *recipeRepository) versionConflict(ctx context.Context, id int64) error {
+	var version int64
+	if err := r.db.QueryRowContext(ctx, "SELECT version FROM recipes WHERE id = $1 AND deleted_at IS NULL", id).Scan(&version); err != nil {
+		return err
+	}
+	return ErrVersionConflict
+}
+
+// inTx runs fn in a transaction, committed when fn succeeds and rolled back otherwise
+func (r *recipeRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+/*
+retryConflict reruns a write while a concurrent write took the revision number or the slug it computed,
+both are read from the snapshot of the statement so the second of two concurrent writes computes the same,
+the failed write is rolled back as a whole so rerunning it is safe
+*/
+func retryConflict(write func() error) error {
+	var err error
+	for attempt := 0; attempt < conflictAttempts; attempt++ {
+		if err = write(); !isUniqueViolation(err, "uq_recipe_revision") && !isUniqueViolation(err, "uq_slug") {
+			return err
+		}
+	}
+	return err
+}
+
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
 }
@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/rhnauf/recipe-api/internal/entity"
 	"reflect"
 	"testing"
@@ -31,6 +33,8 @@ func assertRecipesEqual(t *testing.T, got, want []*entity.Recipe) {
 	}
 }
 
+const transitionQry = "WITH r AS ( UPDATE recipes SET status = $3, version = version + 1, updated_at = now() WHERE id = $1 AND status = $2 AND deleted_at IS NULL RETURNING id, version ) INSERT INTO recipe_status_transitions(recipe_id, from_status, to_status, changed_by) SELECT id, $2, $3, $4 FROM r RETURNING (SELECT version FROM r)"
+
 // should've used suite
 func TestInsertRecipe(t *testing.T) {
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
@@ -39,37 +43,131 @@ func TestInsertRecipe(t *testing.T) {
 	}
 	defer db.Close()
 
-	var b = true
 	recipe := entity.Recipe{
 		Title:       "nasi goreng",
 		Description: "desc nasi goreng",
 		Instruction: "instruction nasi goreng",
-		Publish:     &b,
+		Status:      entity.StatusDraft,
 	}
 
-	repo := NewRecipeRepository(db)
+	repo := NewRecipeRepository(db, Timeouts{})
 
-	qry := "INSERT INTO recipes(title, description, instruction, publish) VALUES ($1, $2, $3, $4)"
+	qry := "WITH r AS ( INSERT INTO recipes(title, description, instruction, status, owner_id, publish_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, title, description, instruction, owner_id ) INSERT INTO recipe_revisions(recipe_id, revision, title, description, instruction, created_by) SELECT id, 1, title, description, instruction, owner_id FROM r RETURNING recipe_id"
 
 	t.Run("should return success on insert query", func(t *testing.T) {
+		mock.ExpectBegin()
 		mock.
-			ExpectExec(qry).
-			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, recipe.Publish).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, entity.StatusDraft, recipe.OwnerId, recipe.PublishAt).
+			WillReturnRows(sqlmock.NewRows([]string{"recipe_id"}).AddRow(1))
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnRows(slugRows())
+		mock.
+			ExpectExec(slugAssignQry).
+			WithArgs(1, "nasi-goreng").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
 
-		err = repo.InsertRecipe(recipe)
+		err = repo.InsertRecipe(context.Background(), recipe)
+		assertErr(t, err, nil)
+	})
+
+	t.Run("should walk a published recipe through review on behalf of its owner", func(t *testing.T) {
+		var ownerId int64 = 7
+		published := recipe
+		published.Status = entity.StatusPublished
+		published.OwnerId = &ownerId
+
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(published.Title, published.Description, published.Instruction, entity.StatusDraft, published.OwnerId, published.PublishAt).
+			WillReturnRows(sqlmock.NewRows([]string{"recipe_id"}).AddRow(1))
+		mock.
+			ExpectQuery(transitionQry).
+			WithArgs(1, entity.StatusDraft, entity.StatusInReview, published.OwnerId).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.
+			ExpectQuery(transitionQry).
+			WithArgs(1, entity.StatusInReview, entity.StatusPublished, published.OwnerId).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnRows(slugRows())
+		mock.
+			ExpectExec(slugAssignQry).
+			WithArgs(1, "nasi-goreng").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = repo.InsertRecipe(context.Background(), published)
+		assertErr(t, err, nil)
+	})
+
+	t.Run("should retry the slug taken by a concurrent write", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, entity.StatusDraft, recipe.OwnerId, recipe.PublishAt).
+			WillReturnRows(sqlmock.NewRows([]string{"recipe_id"}).AddRow(1))
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnRows(slugRows())
+		mock.
+			ExpectExec(slugAssignQry).
+			WithArgs(1, "nasi-goreng").
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_slug"})
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, entity.StatusDraft, recipe.OwnerId, recipe.PublishAt).
+			WillReturnRows(sqlmock.NewRows([]string{"recipe_id"}).AddRow(2))
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnRows(slugRows().AddRow("nasi-goreng", 3, true))
+		mock.
+			ExpectExec(slugAssignQry).
+			WithArgs(2, "nasi-goreng-2").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = repo.InsertRecipe(context.Background(), recipe)
 		assertErr(t, err, nil)
 	})
 
 	t.Run("should return error on insert query", func(t *testing.T) {
+		mock.ExpectBegin()
 		mock.
-			ExpectExec(qry).
-			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, recipe.Publish).
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, entity.StatusDraft, recipe.OwnerId, recipe.PublishAt).
 			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
 
-		err = repo.InsertRecipe(recipe)
+		err = repo.InsertRecipe(context.Background(), recipe)
 		assertErr(t, err, sql.ErrConnDone)
 	})
+
+	t.Run("should return error duplicate title on unique violation", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, entity.StatusDraft, recipe.OwnerId, recipe.PublishAt).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_title"})
+		mock.ExpectRollback()
+
+		err = repo.InsertRecipe(context.Background(), recipe)
+		assertErr(t, err, ErrDuplicateTitle)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
 }
 
 func TestUpdateRecipe(t *testing.T) {
@@ -79,38 +177,179 @@ func TestUpdateRecipe(t *testing.T) {
 	}
 	defer db.Close()
 
-	var b = true
 	recipe := entity.Recipe{
 		Id:          1,
 		Title:       "nasi goreng",
 		Description: "desc nasi goreng",
 		Instruction: "instruction nasi goreng",
-		Publish:     &b,
 	}
 
-	repo := NewRecipeRepository(db)
+	repo := NewRecipeRepository(db, Timeouts{})
 
-	qry := "UPDATE recipes SET title = $1, description = $2, instruction = $3, publish = $4 WHERE id = $5"
+	var editedBy int64 = 7
 
-	t.Run("should return success on update query", func(t *testing.T) {
+	qry := "WITH r AS ( UPDATE recipes SET title = $1, description = $2, instruction = $3, publish_at = $7, version = version + 1, updated_at = now() WHERE id = $4 AND deleted_at IS NULL AND ($6 = 0 OR version = $6) RETURNING id, title, description, instruction, version ) INSERT INTO recipe_revisions(recipe_id, revision, title, description, instruction, created_by) SELECT id, (SELECT COALESCE(MAX(revision), 0) + 1 FROM recipe_revisions WHERE recipe_id = $4), title, description, instruction, $5 FROM r RETURNING (SELECT version FROM r)"
+	versionQry := "SELECT version FROM recipes WHERE id = $1 AND deleted_at IS NULL"
+	statusQry := "SELECT status FROM recipes WHERE id = $1 AND deleted_at IS NULL"
+
+	t.Run("should return new version on update query", func(t *testing.T) {
+		mock.ExpectBegin()
 		mock.
-			ExpectExec(qry).
-			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, recipe.Publish, recipe.Id).
-			WillReturnResult(sqlmock.NewResult(1, 1))
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, recipe.Id, &editedBy, recipe.Version, recipe.PublishAt).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnRows(slugRows().AddRow("nasi-goreng", recipe.Id, true))
+		mock.ExpectCommit()
+
+		got, err := repo.UpdateRecipe(context.Background(), recipe, "", &editedBy)
+		assertErr(t, err, nil)
+		if got != 2 {
+			t.Errorf("got %d, want %d", got, 2)
+		}
+	})
+
+	t.Run("should move the status along with the edit", func(t *testing.T) {
+		archived := recipe
+		archived.Status = entity.StatusArchived
+
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, recipe.Id, &editedBy, recipe.Version, recipe.PublishAt).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.
+			ExpectQuery(transitionQry).
+			WithArgs(recipe.Id, entity.StatusPublished, entity.StatusArchived, &editedBy).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnRows(slugRows().AddRow("nasi-goreng", recipe.Id, true))
+		mock.ExpectCommit()
+
+		got, err := repo.UpdateRecipe(context.Background(), archived, entity.StatusPublished, &editedBy)
+		assertErr(t, err, nil)
+		if got != 3 {
+			t.Errorf("got %d, want %d", got, 3)
+		}
+	})
+
+	t.Run("should roll the edit back when the status changed in the meantime", func(t *testing.T) {
+		archived := recipe
+		archived.Status = entity.StatusArchived
+
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, recipe.Id, &editedBy, recipe.Version, recipe.PublishAt).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+		mock.
+			ExpectQuery(transitionQry).
+			WithArgs(recipe.Id, entity.StatusPublished, entity.StatusArchived, &editedBy).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.
+			ExpectQuery(statusQry).
+			WithArgs(recipe.Id).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.StatusDraft))
+		mock.ExpectRollback()
+
+		_, err := repo.UpdateRecipe(context.Background(), archived, entity.StatusPublished, &editedBy)
+		assertErr(t, err, ErrStatusConflict)
+	})
 
-		err = repo.UpdateRecipe(recipe)
+	t.Run("should retry the revision number taken by a concurrent update", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, recipe.Id, &editedBy, recipe.Version, recipe.PublishAt).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_recipe_revision"})
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, recipe.Id, &editedBy, recipe.Version, recipe.PublishAt).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnRows(slugRows().AddRow("nasi-goreng", recipe.Id, true))
+		mock.ExpectCommit()
+
+		got, err := repo.UpdateRecipe(context.Background(), recipe, "", &editedBy)
 		assertErr(t, err, nil)
+		if got != 3 {
+			t.Errorf("got %d, want %d", got, 3)
+		}
 	})
 
 	t.Run("should return error on update query", func(t *testing.T) {
+		mock.ExpectBegin()
 		mock.
-			ExpectExec(qry).
-			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, recipe.Publish, recipe.Id).
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, recipe.Id, &editedBy, recipe.Version, recipe.PublishAt).
 			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
 
-		err = repo.UpdateRecipe(recipe)
+		_, err = repo.UpdateRecipe(context.Background(), recipe, "", &editedBy)
 		assertErr(t, err, sql.ErrConnDone)
 	})
+
+	t.Run("should return error duplicate title on unique violation", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipe.Title, recipe.Description, recipe.Instruction, recipe.Id, &editedBy, recipe.Version, recipe.PublishAt).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_title"})
+		mock.ExpectRollback()
+
+		_, err = repo.UpdateRecipe(context.Background(), recipe, "", &editedBy)
+		assertErr(t, err, ErrDuplicateTitle)
+	})
+
+	t.Run("should return error version conflict on stale update", func(t *testing.T) {
+		stale := recipe
+		stale.Version = 1
+
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(stale.Title, stale.Description, stale.Instruction, stale.Id, &editedBy, stale.Version, stale.PublishAt).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.ExpectRollback()
+		mock.
+			ExpectQuery(versionQry).
+			WithArgs(stale.Id).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+
+		_, err = repo.UpdateRecipe(context.Background(), stale, "", &editedBy)
+		assertErr(t, err, ErrVersionConflict)
+	})
+
+	t.Run("should return error not found on conditional update of missing recipe", func(t *testing.T) {
+		stale := recipe
+		stale.Version = 1
+
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(stale.Title, stale.Description, stale.Instruction, stale.Id, &editedBy, stale.Version, stale.PublishAt).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.ExpectRollback()
+		mock.
+			ExpectQuery(versionQry).
+			WithArgs(stale.Id).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err = repo.UpdateRecipe(context.Background(), stale, "", &editedBy)
+		assertErr(t, err, sql.ErrNoRows)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
 }
 
 func TestGetRecipeById(t *testing.T) {
@@ -123,32 +362,36 @@ func TestGetRecipeById(t *testing.T) {
 	var idSuccess int64 = 1
 	var idNotFound int64 = 2
 
-	repo := NewRecipeRepository(db)
+	viewer := entity.Caller{Id: 7}
+
+	repo := NewRecipeRepository(db, Timeouts{})
 
-	qry := "SELECT * FROM recipes WHERE id = $1"
+	qry := "SELECT id, created_at, updated_at, title, COALESCE(slug, ''), description, instruction, status, owner_id, forked_from_id, version, publish_at FROM recipes WHERE id = $1 AND deleted_at IS NULL AND ((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $2 OR $3)"
 
 	t.Run("should return success on get by id query", func(t *testing.T) {
 		now := time.Now()
 
 		recipeRow := sqlmock.
-			NewRows([]string{"id", "created_at", "title", "description", "instruction", "publish"}).
-			AddRow(1, now, "nasi goreng", "nasi goreng desc", "nasi goreng instruction", true)
+			NewRows([]string{"id", "created_at", "updated_at", "title", "slug", "description", "instruction", "status", "owner_id", "forked_from_id", "version", "publish_at"}).
+			AddRow(1, now, now, "nasi goreng", "nasi-goreng", "nasi goreng desc", "nasi goreng instruction", "published", nil, nil, 3, nil)
 
 		mock.
 			ExpectQuery(qry).
-			WithArgs(idSuccess).
+			WithArgs(idSuccess, viewer.Id, viewer.CanSeeDrafts()).
 			WillReturnRows(recipeRow)
 
-		got, err := repo.GetRecipeById(idSuccess)
+		got, err := repo.GetRecipeById(context.Background(), viewer, idSuccess)
 
-		var p = true
 		want := &entity.Recipe{
 			Id:          1,
 			Title:       "nasi goreng",
+			Slug:        "nasi-goreng",
 			Description: "nasi goreng desc",
 			Instruction: "nasi goreng instruction",
-			Publish:     &p,
+			Status:      entity.StatusPublished,
+			Version:     3,
 			CreatedAt:   now,
+			UpdatedAt:   now,
 		}
 
 		assertErr(t, err, nil)
@@ -158,10 +401,10 @@ func TestGetRecipeById(t *testing.T) {
 	t.Run("should return error not found on get by id query", func(t *testing.T) {
 		mock.
 			ExpectQuery(qry).
-			WithArgs(idNotFound).
+			WithArgs(idNotFound, viewer.Id, viewer.CanSeeDrafts()).
 			WillReturnError(sql.ErrNoRows)
 
-		got, err := repo.GetRecipeById(idNotFound)
+		got, err := repo.GetRecipeById(context.Background(), viewer, idNotFound)
 
 		assertErr(t, err, sql.ErrNoRows)
 		assertRecipeEqual(t, got, nil)
@@ -177,31 +420,57 @@ func TestDeleteRecipeById(t *testing.T) {
 
 	var id int64 = 1
 
-	repo := NewRecipeRepository(db)
+	repo := NewRecipeRepository(db, Timeouts{})
 
-	qry := "DELETE FROM recipes WHERE id = $1"
+	qry := "UPDATE recipes SET deleted_at = now(), version = version + 1, updated_at = now() WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)"
 
 	t.Run("should return success on delete by id query", func(t *testing.T) {
 		mock.
 			ExpectExec(qry).
-			WithArgs(id).
+			WithArgs(id, 0).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
-		err = repo.DeleteRecipeById(id)
+		err = repo.DeleteRecipeById(context.Background(), id, 0)
 
 		assertErr(t, err, nil)
 	})
 
+	t.Run("should return error not found when nothing is deleted", func(t *testing.T) {
+		mock.
+			ExpectExec(qry).
+			WithArgs(id, 0).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = repo.DeleteRecipeById(context.Background(), id, 0)
+
+		assertErr(t, err, sql.ErrNoRows)
+	})
+
 	t.Run("should return error on delete by id query", func(t *testing.T) {
 		mock.
 			ExpectExec(qry).
-			WithArgs(id).
+			WithArgs(id, 0).
 			WillReturnError(sql.ErrConnDone)
 
-		err = repo.DeleteRecipeById(id)
+		err = repo.DeleteRecipeById(context.Background(), id, 0)
 
 		assertErr(t, err, sql.ErrConnDone)
 	})
+
+	t.Run("should return error version conflict on stale delete", func(t *testing.T) {
+		mock.
+			ExpectExec(qry).
+			WithArgs(id, 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.
+			ExpectQuery("SELECT version FROM recipes WHERE id = $1 AND deleted_at IS NULL").
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+
+		err = repo.DeleteRecipeById(context.Background(), id, 1)
+
+		assertErr(t, err, ErrVersionConflict)
+	})
 }
 
 func TestGetListRecipe(t *testing.T) {
@@ -214,26 +483,33 @@ func TestGetListRecipe(t *testing.T) {
 	var offset int64 = 1
 	var limit int64 = 10
 
-	repo := NewRecipeRepository(db)
+	viewer := entity.Caller{Id: 7, Role: entity.RoleEditor}
 
-	qry := "SELECT id, title FROM recipes LIMIT $1 OFFSET $2"
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "SELECT id, title, COALESCE(slug, ''), version, updated_at FROM recipes WHERE deleted_at IS NULL AND ((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $3 OR $4) LIMIT $1 OFFSET $2"
 
 	t.Run("should return success on get list query", func(t *testing.T) {
+		now := time.Now()
+
 		recipeRows := sqlmock.
-			NewRows([]string{"id", "title"}).
-			AddRow(1, "nasi goreng")
+			NewRows([]string{"id", "title", "slug", "version", "updated_at"}).
+			AddRow(1, "nasi goreng", "nasi-goreng", 2, now)
 
 		mock.
 			ExpectQuery(qry).
-			WithArgs(limit, offset).
+			WithArgs(limit, offset, viewer.Id, viewer.CanSeeDrafts()).
 			WillReturnRows(recipeRows)
 
-		got, err := repo.GetListRecipe(limit, offset)
+		got, err := repo.GetListRecipe(context.Background(), viewer, limit, offset)
 
 		want := []*entity.Recipe{
 			{
-				Id:    1,
-				Title: "nasi goreng",
+				Id:        1,
+				Title:     "nasi goreng",
+				Slug:      "nasi-goreng",
+				Version:   2,
+				UpdatedAt: now,
 			},
 		}
 
@@ -241,31 +517,47 @@ func TestGetListRecipe(t *testing.T) {
 		assertRecipesEqual(t, got, want)
 	})
 
-	t.Run("should return empty slice error on scanning rows", func(t *testing.T) {
+	t.Run("should return error on scanning rows", func(t *testing.T) {
 		recipeRows := sqlmock.
-			NewRows([]string{"id", "title"}).
-			AddRow("invalid", "nasi goreng")
+			NewRows([]string{"id", "title", "slug", "version", "updated_at"}).
+			AddRow("invalid", "nasi goreng", "nasi-goreng", 1, time.Now())
 
 		mock.
 			ExpectQuery(qry).
-			WithArgs(limit, offset).
+			WithArgs(limit, offset, viewer.Id, viewer.CanSeeDrafts()).
 			WillReturnRows(recipeRows)
 
-		got, err := repo.GetListRecipe(limit, offset)
+		got, err := repo.GetListRecipe(context.Background(), viewer, limit, offset)
 
-		var want []*entity.Recipe
+		if err == nil {
+			t.Errorf("got nil, want scan error")
+		}
+		assertRecipesEqual(t, got, nil)
+	})
 
-		assertErr(t, err, nil)
-		assertRecipesEqual(t, got, want)
+	t.Run("should return error breaking off while iterating rows", func(t *testing.T) {
+		recipeRows := sqlmock.
+			NewRows([]string{"id", "title", "slug", "version", "updated_at"}).
+			AddRow(1, "nasi goreng", "nasi-goreng", 1, time.Now()).
+			RowError(0, sql.ErrConnDone)
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs(limit, offset, viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(recipeRows)
+
+		_, err := repo.GetListRecipe(context.Background(), viewer, limit, offset)
+
+		assertErr(t, err, sql.ErrConnDone)
 	})
 
 	t.Run("should return error getting list", func(t *testing.T) {
 		mock.
 			ExpectQuery(qry).
-			WithArgs(limit, offset).
+			WithArgs(limit, offset, viewer.Id, viewer.CanSeeDrafts()).
 			WillReturnError(sql.ErrConnDone)
 
-		got, err := repo.GetListRecipe(limit, offset)
+		got, err := repo.GetListRecipe(context.Background(), viewer, limit, offset)
 
 		assertErr(t, err, sql.ErrConnDone)
 		assertRecipesEqual(t, got, nil)
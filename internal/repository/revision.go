@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+// revisions are only readable by the viewers who can see the recipe itself
+func (r *recipeRepository) GetRecipeRevisions(ctx context.Context, viewer entity.Caller, recipeId int64) ([]*entity.Revision, error) {
+	ctx, cancel := r.timeouts.context(ctx, "GetRecipeRevisions")
+	defer cancel()
+
+	var revisions []*entity.Revision
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, recipe_id, revision, title, created_by, created_at
+		FROM recipe_revisions
+		WHERE recipe_id = $1 AND EXISTS (
+			SELECT 1 FROM recipes
+			WHERE id = $1 AND deleted_at IS NULL AND `+visibleTo("", 2, 3)+`
+		)
+		ORDER BY revision DESC`, recipeId, viewer.Id, viewer.CanSeeDrafts())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision entity.Revision
+		if err := rows.Scan(
+			&revision.Id,
+			&revision.RecipeId,
+			&revision.Revision,
+			&revision.Title,
+			&revision.CreatedBy,
+			&revision.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, &revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (r *recipeRepository) GetRecipeRevision(ctx context.Context, viewer entity.Caller, recipeId, revision int64) (*entity.Revision, error) {
+	ctx, cancel := r.timeouts.context(ctx, "GetRecipeRevision")
+	defer cancel()
+
+	var rev entity.Revision
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, recipe_id, revision, title, description, instruction, created_by, created_at
+		FROM recipe_revisions
+		WHERE recipe_id = $1 AND revision = $2 AND EXISTS (
+			SELECT 1 FROM recipes
+			WHERE id = $1 AND deleted_at IS NULL AND `+visibleTo("", 3, 4)+`
+		)`,
+		recipeId, revision, viewer.Id, viewer.CanSeeDrafts()).
+		Scan(
+			&rev.Id,
+			&rev.RecipeId,
+			&rev.Revision,
+			&rev.Title,
+			&rev.Description,
+			&rev.Instruction,
+			&rev.CreatedBy,
+			&rev.CreatedAt,
+		)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rev, nil
+}
+
+/*
+restoring never rewrites history, the content of the old revision is copied back to the recipe
+and stored as a new revision, only by the author or the editorial team, sql.ErrNoRows is returned when the recipe
+or revision does not exist or the recipe is not managed by restoredBy
+*/
+func (r *recipeRepository) RestoreRecipeRevision(ctx context.Context, restoredBy entity.Caller, recipeId, revision int64) (int64, error) {
+	ctx, cancel := r.timeouts.context(ctx, "RestoreRecipeRevision")
+	defer cancel()
+
+	var newRevision int64
+	var title string
+
+	err := retryConflict(func() error {
+		return r.inTx(ctx, func(tx *sql.Tx) error {
+			err := tx.QueryRowContext(ctx, `
+				WITH rev AS (
+					SELECT title, description, instruction
+					FROM recipe_revisions WHERE recipe_id = $1 AND revision = $2
+				), r AS (
+					UPDATE recipes
+					SET title = rev.title, description = rev.description, instruction = rev.instruction,
+						version = version + 1, updated_at = now()
+					FROM rev
+					WHERE recipes.id = $1 AND recipes.deleted_at IS NULL AND (recipes.owner_id = $3 OR $4)
+					RETURNING recipes.id, recipes.title, recipes.description, recipes.instruction
+				)
+				INSERT INTO recipe_revisions(recipe_id, revision, title, description, instruction, created_by)
+				SELECT id, (SELECT COALESCE(MAX(revision), 0) + 1 FROM recipe_revisions WHERE recipe_id = $1),
+					title, description, instruction, $3
+				FROM r
+				RETURNING revision, title`,
+				recipeId,
+				revision,
+				restoredBy.Id,
+				restoredBy.CanSeeDrafts(),
+			).Scan(&newRevision, &title)
+			if err != nil {
+				return err
+			}
+
+			return assignSlug(ctx, tx, recipeId, title)
+		})
+	})
+	if isUniqueViolation(err, "uq_title") {
+		return 0, ErrDuplicateTitle
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return newRevision, nil
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func TestGetRecipeRevisions(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var recipeId int64 = 1
+
+	viewer := entity.Caller{Id: 7}
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "SELECT id, recipe_id, revision, title, created_by, created_at FROM recipe_revisions WHERE recipe_id = $1 AND EXISTS ( SELECT 1 FROM recipes WHERE id = $1 AND deleted_at IS NULL AND ((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $2 OR $3) ) ORDER BY revision DESC"
+
+	t.Run("should return revisions newest first", func(t *testing.T) {
+		now := time.Now()
+
+		rows := sqlmock.
+			NewRows([]string{"id", "recipe_id", "revision", "title", "created_by", "created_at"}).
+			AddRow(11, 1, 2, "nasi goreng spesial", 7, now).
+			AddRow(10, 1, 1, "nasi goreng", nil, now)
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipeId, viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(rows)
+
+		got, err := repo.GetRecipeRevisions(context.Background(), viewer, recipeId)
+
+		var createdBy int64 = 7
+		want := []*entity.Revision{
+			{Id: 11, RecipeId: 1, Revision: 2, Title: "nasi goreng spesial", CreatedBy: &createdBy, CreatedAt: now},
+			{Id: 10, RecipeId: 1, Revision: 1, Title: "nasi goreng", CreatedAt: now},
+		}
+
+		assertErr(t, err, nil)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("should return error getting revisions", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipeId, viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnError(sql.ErrConnDone)
+
+		got, err := repo.GetRecipeRevisions(context.Background(), viewer, recipeId)
+
+		assertErr(t, err, sql.ErrConnDone)
+		if got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+}
+
+func TestGetRecipeRevision(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var recipeId int64 = 1
+	var revision int64 = 2
+
+	viewer := entity.Caller{Id: 7}
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "SELECT id, recipe_id, revision, title, description, instruction, created_by, created_at FROM recipe_revisions WHERE recipe_id = $1 AND revision = $2 AND EXISTS ( SELECT 1 FROM recipes WHERE id = $1 AND deleted_at IS NULL AND ((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $3 OR $4) )"
+
+	t.Run("should return success on get revision query", func(t *testing.T) {
+		now := time.Now()
+
+		rows := sqlmock.
+			NewRows([]string{"id", "recipe_id", "revision", "title", "description", "instruction", "created_by", "created_at"}).
+			AddRow(11, 1, 2, "nasi goreng", "nasi goreng desc", "nasi goreng instruction", nil, now)
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipeId, revision, viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(rows)
+
+		got, err := repo.GetRecipeRevision(context.Background(), viewer, recipeId, revision)
+
+		want := &entity.Revision{
+			Id:          11,
+			RecipeId:    1,
+			Revision:    2,
+			Title:       "nasi goreng",
+			Description: "nasi goreng desc",
+			Instruction: "nasi goreng instruction",
+			CreatedAt:   now,
+		}
+
+		assertErr(t, err, nil)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("should return error not found on get revision query", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipeId, revision, viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnError(sql.ErrNoRows)
+
+		got, err := repo.GetRecipeRevision(context.Background(), viewer, recipeId, revision)
+
+		assertErr(t, err, sql.ErrNoRows)
+		if got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+}
+
+func TestRestoreRecipeRevision(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var recipeId int64 = 1
+	var revision int64 = 1
+	restoredBy := entity.Caller{Id: 7}
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "WITH rev AS ( SELECT title, description, instruction FROM recipe_revisions WHERE recipe_id = $1 AND revision = $2 ), r AS ( UPDATE recipes SET title = rev.title, description = rev.description, instruction = rev.instruction, version = version + 1, updated_at = now() FROM rev WHERE recipes.id = $1 AND recipes.deleted_at IS NULL AND (recipes.owner_id = $3 OR $4) RETURNING recipes.id, recipes.title, recipes.description, recipes.instruction ) INSERT INTO recipe_revisions(recipe_id, revision, title, description, instruction, created_by) SELECT id, (SELECT COALESCE(MAX(revision), 0) + 1 FROM recipe_revisions WHERE recipe_id = $1), title, description, instruction, $3 FROM r RETURNING revision, title"
+
+	t.Run("should return the new revision on restore", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipeId, revision, restoredBy.Id, restoredBy.CanSeeDrafts()).
+			WillReturnRows(sqlmock.NewRows([]string{"revision", "title"}).AddRow(3, "nasi goreng"))
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnRows(slugRows().AddRow("nasi-goreng", recipeId, false))
+		mock.
+			ExpectExec(slugAssignQry).
+			WithArgs(recipeId, "nasi-goreng").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		got, err := repo.RestoreRecipeRevision(context.Background(), restoredBy, recipeId, revision)
+
+		assertErr(t, err, nil)
+		if got != 3 {
+			t.Errorf("got %d, want %d", got, 3)
+		}
+	})
+
+	t.Run("should return error not found when revision does not exist", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.
+			ExpectQuery(qry).
+			WithArgs(recipeId, revision, restoredBy.Id, restoredBy.CanSeeDrafts()).
+			WillReturnRows(sqlmock.NewRows([]string{"revision", "title"}))
+		mock.ExpectRollback()
+
+		got, err := repo.RestoreRecipeRevision(context.Background(), restoredBy, recipeId, revision)
+
+		assertErr(t, err, sql.ErrNoRows)
+		if got != 0 {
+			t.Errorf("got %d, want %d", got, 0)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package repository
+
+import "context"
+
+/*
+the due recipes are locked with SKIP LOCKED so concurrent instances publish disjoint batches,
+the move is audited like any other transition without a user behind it
+*/
+func (r *recipeRepository) PublishDueRecipes(ctx context.Context, limit int) (int64, error) {
+	ctx, cancel := r.timeouts.context(ctx, "PublishDueRecipes")
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `
+		WITH due AS (
+			SELECT id, status FROM recipes
+			WHERE status = 'in_review' AND publish_at <= now() AND deleted_at IS NULL
+			ORDER BY publish_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), r AS (
+			UPDATE recipes
+			SET status = 'published', version = version + 1, updated_at = now()
+			FROM due
+			WHERE recipes.id = due.id
+			RETURNING recipes.id, due.status
+		)
+		INSERT INTO recipe_status_transitions(recipe_id, from_status, to_status)
+		SELECT id, status, 'published' FROM r`,
+		limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPublishDueRecipes(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "WITH due AS ( SELECT id, status FROM recipes WHERE status = 'in_review' AND publish_at <= now() AND deleted_at IS NULL ORDER BY publish_at LIMIT $1 FOR UPDATE SKIP LOCKED ), r AS ( UPDATE recipes SET status = 'published', version = version + 1, updated_at = now() FROM due WHERE recipes.id = due.id RETURNING recipes.id, due.status ) INSERT INTO recipe_status_transitions(recipe_id, from_status, to_status) SELECT id, status, 'published' FROM r"
+
+	t.Run("should return the number of published recipes", func(t *testing.T) {
+		mock.
+			ExpectExec(qry).
+			WithArgs(100).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		got, err := repo.PublishDueRecipes(context.Background(), 100)
+
+		assertErr(t, err, nil)
+		if got != 2 {
+			t.Errorf("got %d, want %d", got, 2)
+		}
+	})
+
+	t.Run("should return error on publish query", func(t *testing.T) {
+		mock.
+			ExpectExec(qry).
+			WithArgs(100).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.PublishDueRecipes(context.Background(), 100)
+
+		assertErr(t, err, sql.ErrConnDone)
+	})
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"github.com/rhnauf/recipe-api/internal/entity"
+	"github.com/rhnauf/recipe-api/internal/helper"
+)
+
+func (r *recipeRepository) GetRecipeBySlug(ctx context.Context, viewer entity.Caller, slug string) (*entity.Recipe, error) {
+	ctx, cancel := r.timeouts.context(ctx, "GetRecipeBySlug")
+	defer cancel()
+
+	var recipe entity.Recipe
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, created_at, updated_at, title, slug, description, instruction, status, owner_id, forked_from_id, version, publish_at
+		FROM recipes
+		WHERE slug = $1 AND deleted_at IS NULL AND `+visibleTo("", 2, 3),
+		slug, viewer.Id, viewer.CanSeeDrafts()).
+		Scan(
+			&recipe.Id,
+			&recipe.CreatedAt,
+			&recipe.UpdatedAt,
+			&recipe.Title,
+			&recipe.Slug,
+			&recipe.Description,
+			&recipe.Instruction,
+			&recipe.Status,
+			&recipe.OwnerId,
+			&recipe.ForkedFromId,
+			&recipe.Version,
+			&recipe.PublishAt,
+		)
+	if err != nil {
+		return nil, err
+	}
+
+	return &recipe, nil
+}
+
+// GetRecipeSlugRedirect resolves a slug the recipe had before its title changed to the current one
+func (r *recipeRepository) GetRecipeSlugRedirect(ctx context.Context, viewer entity.Caller, slug string) (string, error) {
+	ctx, cancel := r.timeouts.context(ctx, "GetRecipeSlugRedirect")
+	defer cancel()
+
+	var current string
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT r.slug
+		FROM recipe_slug_redirects s JOIN recipes r ON r.id = s.recipe_id
+		WHERE s.slug = $1 AND r.deleted_at IS NULL AND `+visibleTo("r", 2, 3),
+		slug, viewer.Id, viewer.CanSeeDrafts()).
+		Scan(&current)
+	if err != nil {
+		return "", err
+	}
+
+	return current, nil
+}
+
+/*
+assignSlug keeps the slug of the recipe in line with its title within the transaction of every write
+that can change it, the current slug is kept as long as it was made from the same title, otherwise
+the first free slug is taken and the old one is stored as a redirect, a recipe may take back its own
+old slugs, a slug taken by a concurrent write fails on uq_slug and the write is retried
+*/
+func assignSlug(ctx context.Context, q querier, id int64, title string) error {
+	base := helper.Slugify(title)
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT slug, recipe_id, false FROM recipe_slug_redirects WHERE slug = $1 OR slug LIKE $1 || '-%'
+		UNION ALL
+		SELECT slug, id, true FROM recipes WHERE slug = $1 OR slug LIKE $1 || '-%'`, base)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	taken := make(map[string]bool)
+	for rows.Next() {
+		var slug string
+		var recipeId int64
+		var current bool
+		if err := rows.Scan(&slug, &recipeId, &current); err != nil {
+			return err
+		}
+		if !helper.HasSlugBase(slug, base) {
+			continue
+		}
+		if recipeId == id {
+			if current {
+				return nil
+			}
+			continue
+		}
+		taken[slug] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, `
+		WITH old AS (
+			SELECT slug FROM recipes WHERE id = $1 AND slug IS NOT NULL
+		), reclaimed AS (
+			DELETE FROM recipe_slug_redirects WHERE slug = $2 AND recipe_id = $1
+		), redirect AS (
+			INSERT INTO recipe_slug_redirects(slug, recipe_id)
+			SELECT slug, $1 FROM old
+		)
+		UPDATE recipes SET slug = $2 WHERE id = $1`,
+		id,
+		helper.NextSlug(base, taken),
+	)
+
+	return err
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+const (
+	slugLookupQry = "SELECT slug, recipe_id, false FROM recipe_slug_redirects WHERE slug = $1 OR slug LIKE $1 || '-%' UNION ALL SELECT slug, id, true FROM recipes WHERE slug = $1 OR slug LIKE $1 || '-%'"
+	slugAssignQry = "WITH old AS ( SELECT slug FROM recipes WHERE id = $1 AND slug IS NOT NULL ), reclaimed AS ( DELETE FROM recipe_slug_redirects WHERE slug = $2 AND recipe_id = $1 ), redirect AS ( INSERT INTO recipe_slug_redirects(slug, recipe_id) SELECT slug, $1 FROM old ) UPDATE recipes SET slug = $2 WHERE id = $1"
+)
+
+func slugRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"slug", "recipe_id", "current"})
+}
+
+func TestAssignSlug(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var id int64 = 1
+
+	t.Run("should take the base slug when it is free", func(t *testing.T) {
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnRows(slugRows().AddRow("nasi-goreng-kampung", 5, true))
+		mock.
+			ExpectExec(slugAssignQry).
+			WithArgs(id, "nasi-goreng").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := assignSlug(context.Background(), db, id, "Nasi Goreng")
+		assertErr(t, err, nil)
+	})
+
+	t.Run("should suffix the slug on collision", func(t *testing.T) {
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("creme-brulee").
+			WillReturnRows(slugRows().AddRow("creme-brulee", 2, true).AddRow("creme-brulee-2", 3, false))
+		mock.
+			ExpectExec(slugAssignQry).
+			WithArgs(id, "creme-brulee-3").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := assignSlug(context.Background(), db, id, "Crème Brûlée")
+		assertErr(t, err, nil)
+	})
+
+	t.Run("should keep the current slug made from the same title", func(t *testing.T) {
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnRows(slugRows().AddRow("nasi-goreng", 2, true).AddRow("nasi-goreng-2", id, true))
+
+		err := assignSlug(context.Background(), db, id, "nasi goreng")
+		assertErr(t, err, nil)
+	})
+
+	t.Run("should take back an own old slug", func(t *testing.T) {
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnRows(slugRows().AddRow("nasi-goreng", id, false))
+		mock.
+			ExpectExec(slugAssignQry).
+			WithArgs(id, "nasi-goreng").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := assignSlug(context.Background(), db, id, "nasi goreng")
+		assertErr(t, err, nil)
+	})
+
+	t.Run("should return error on slug lookup", func(t *testing.T) {
+		mock.
+			ExpectQuery(slugLookupQry).
+			WithArgs("nasi-goreng").
+			WillReturnError(sql.ErrConnDone)
+
+		err := assignSlug(context.Background(), db, id, "nasi goreng")
+		assertErr(t, err, sql.ErrConnDone)
+	})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetRecipeBySlug(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	viewer := entity.Caller{Id: 7}
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "SELECT id, created_at, updated_at, title, slug, description, instruction, status, owner_id, forked_from_id, version, publish_at FROM recipes WHERE slug = $1 AND deleted_at IS NULL AND ((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $2 OR $3)"
+
+	t.Run("should return success on get by slug query", func(t *testing.T) {
+		now := time.Now()
+
+		recipeRow := sqlmock.
+			NewRows([]string{"id", "created_at", "updated_at", "title", "slug", "description", "instruction", "status", "owner_id", "forked_from_id", "version", "publish_at"}).
+			AddRow(1, now, now, "nasi goreng", "nasi-goreng", "nasi goreng desc", "nasi goreng instruction", "published", nil, nil, 3, nil)
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs("nasi-goreng", viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(recipeRow)
+
+		got, err := repo.GetRecipeBySlug(context.Background(), viewer, "nasi-goreng")
+
+		want := &entity.Recipe{
+			Id:          1,
+			Title:       "nasi goreng",
+			Slug:        "nasi-goreng",
+			Description: "nasi goreng desc",
+			Instruction: "nasi goreng instruction",
+			Status:      entity.StatusPublished,
+			Version:     3,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+
+		assertErr(t, err, nil)
+		assertRecipeEqual(t, got, want)
+	})
+
+	t.Run("should return error not found on get by slug query", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs("rendang", viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnError(sql.ErrNoRows)
+
+		got, err := repo.GetRecipeBySlug(context.Background(), viewer, "rendang")
+
+		assertErr(t, err, sql.ErrNoRows)
+		assertRecipeEqual(t, got, nil)
+	})
+}
+
+func TestGetRecipeSlugRedirect(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	viewer := entity.Caller{Id: 7}
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "SELECT r.slug FROM recipe_slug_redirects s JOIN recipes r ON r.id = s.recipe_id WHERE s.slug = $1 AND r.deleted_at IS NULL AND ((r.status = 'published' AND (r.publish_at IS NULL OR r.publish_at <= now())) OR r.owner_id = $2 OR $3)"
+
+	t.Run("should return the current slug", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs("nasi-goreng-biasa", viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("nasi-goreng"))
+
+		got, err := repo.GetRecipeSlugRedirect(context.Background(), viewer, "nasi-goreng-biasa")
+
+		assertErr(t, err, nil)
+		if got != "nasi-goreng" {
+			t.Errorf("got %q, want %q", got, "nasi-goreng")
+		}
+	})
+
+	t.Run("should return error not found for unknown slug", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs("rendang", viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetRecipeSlugRedirect(context.Background(), viewer, "rendang")
+
+		assertErr(t, err, sql.ErrNoRows)
+	})
+}
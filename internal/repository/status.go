@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+/*
+the status only moves when it is still the one the caller validated the transition against,
+every move is recorded in the audit table within the same statement and the new version is returned
+*/
+func (r *recipeRepository) TransitionRecipeStatus(ctx context.Context, id int64, from, to string, changedBy *int64) (int64, error) {
+	ctx, cancel := r.timeouts.context(ctx, "TransitionRecipeStatus")
+	defer cancel()
+
+	return transitionStatus(ctx, r.db, id, from, to, changedBy)
+}
+
+// transitionStatus runs on the transaction of UpdateRecipe when the status moves along with an edit
+func transitionStatus(ctx context.Context, q querier, id int64, from, to string, changedBy *int64) (int64, error) {
+	var version int64
+
+	err := q.QueryRowContext(ctx, `
+		WITH r AS (
+			UPDATE recipes
+			SET status = $3, version = version + 1, updated_at = now()
+			WHERE id = $1 AND status = $2 AND deleted_at IS NULL
+			RETURNING id, version
+		)
+		INSERT INTO recipe_status_transitions(recipe_id, from_status, to_status, changed_by)
+		SELECT id, $2, $3, $4 FROM r
+		RETURNING (SELECT version FROM r)`,
+		id,
+		from,
+		to,
+		changedBy,
+	).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, statusConflict(ctx, q, id)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+func (r *recipeRepository) GetRecipeStatusTransitions(ctx context.Context, viewer entity.Caller, id int64) ([]*entity.StatusTransition, error) {
+	ctx, cancel := r.timeouts.context(ctx, "GetRecipeStatusTransitions")
+	defer cancel()
+
+	var transitions []*entity.StatusTransition
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, recipe_id, from_status, to_status, changed_by, changed_at
+		FROM recipe_status_transitions
+		WHERE recipe_id = $1 AND EXISTS (
+			SELECT 1 FROM recipes
+			WHERE id = $1 AND deleted_at IS NULL AND `+visibleTo("", 2, 3)+`
+		)
+		ORDER BY changed_at, id`, id, viewer.Id, viewer.CanSeeDrafts())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transition entity.StatusTransition
+		if err := rows.Scan(
+			&transition.Id,
+			&transition.RecipeId,
+			&transition.FromStatus,
+			&transition.ToStatus,
+			&transition.ChangedBy,
+			&transition.ChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, &transition)
+	}
+
+	return transitions, rows.Err()
+}
+
+func statusConflict(ctx context.Context, q querier, id int64) error {
+	var status string
+	if err := q.QueryRowContext(ctx, "SELECT status FROM recipes WHERE id = $1 AND deleted_at IS NULL", id).Scan(&status); err != nil {
+		return err
+	}
+	return ErrStatusConflict
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func TestTransitionRecipeStatus(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var id int64 = 1
+	var changedBy int64 = 7
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "WITH r AS ( UPDATE recipes SET status = $3, version = version + 1, updated_at = now() WHERE id = $1 AND status = $2 AND deleted_at IS NULL RETURNING id, version ) INSERT INTO recipe_status_transitions(recipe_id, from_status, to_status, changed_by) SELECT id, $2, $3, $4 FROM r RETURNING (SELECT version FROM r)"
+	statusQry := "SELECT status FROM recipes WHERE id = $1 AND deleted_at IS NULL"
+
+	t.Run("should return new version on transition", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, entity.StatusDraft, entity.StatusInReview, &changedBy).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+
+		got, err := repo.TransitionRecipeStatus(context.Background(), id, entity.StatusDraft, entity.StatusInReview, &changedBy)
+
+		assertErr(t, err, nil)
+		if got != 4 {
+			t.Errorf("got %d, want %d", got, 4)
+		}
+	})
+
+	t.Run("should return error status conflict when status moved in the meantime", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, entity.StatusDraft, entity.StatusInReview, &changedBy).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.
+			ExpectQuery(statusQry).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(entity.StatusInReview))
+
+		_, err := repo.TransitionRecipeStatus(context.Background(), id, entity.StatusDraft, entity.StatusInReview, &changedBy)
+
+		assertErr(t, err, ErrStatusConflict)
+	})
+
+	t.Run("should return error not found when recipe does not exist", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, entity.StatusDraft, entity.StatusInReview, &changedBy).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}))
+		mock.
+			ExpectQuery(statusQry).
+			WithArgs(id).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.TransitionRecipeStatus(context.Background(), id, entity.StatusDraft, entity.StatusInReview, &changedBy)
+
+		assertErr(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestGetRecipeStatusTransitions(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var id int64 = 1
+
+	viewer := entity.Caller{Id: 7, Role: entity.RoleAdmin}
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "SELECT id, recipe_id, from_status, to_status, changed_by, changed_at FROM recipe_status_transitions WHERE recipe_id = $1 AND EXISTS ( SELECT 1 FROM recipes WHERE id = $1 AND deleted_at IS NULL AND ((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $2 OR $3) ) ORDER BY changed_at, id"
+
+	t.Run("should return the audit trail", func(t *testing.T) {
+		now := time.Now()
+
+		rows := sqlmock.
+			NewRows([]string{"id", "recipe_id", "from_status", "to_status", "changed_by", "changed_at"}).
+			AddRow(1, 1, "draft", "in_review", 7, now).
+			AddRow(2, 1, "in_review", "published", nil, now)
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnRows(rows)
+
+		got, err := repo.GetRecipeStatusTransitions(context.Background(), viewer, id)
+
+		var changedBy int64 = 7
+		want := []*entity.StatusTransition{
+			{Id: 1, RecipeId: 1, FromStatus: entity.StatusDraft, ToStatus: entity.StatusInReview, ChangedBy: &changedBy, ChangedAt: now},
+			{Id: 2, RecipeId: 1, FromStatus: entity.StatusInReview, ToStatus: entity.StatusPublished, ChangedAt: now},
+		}
+
+		assertErr(t, err, nil)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("should return error getting audit trail", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(id, viewer.Id, viewer.CanSeeDrafts()).
+			WillReturnError(sql.ErrConnDone)
+
+		got, err := repo.GetRecipeStatusTransitions(context.Background(), viewer, id)
+
+		assertErr(t, err, sql.ErrConnDone)
+		if got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+/*
+Timeouts bounds the queries of every repository operation, the operations are named after
+the methods of the repositories, an operation without its own timeout gets Default
+and a zero duration leaves the deadline to the context of the caller
+*/
+type Timeouts struct {
+	Default    time.Duration
+	Operations map[string]time.Duration
+}
+
+// exports and the background jobs go through many rows so they get more time than a request
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Default: 5 * time.Second,
+		Operations: map[string]time.Duration{
+			"ExportRecipes":       2 * time.Minute,
+			"PurgeDeletedRecipes": time.Minute,
+			"PublishDueRecipes":   30 * time.Second,
+		},
+	}
+}
+
+// Set overrides the timeout of one operation, an unknown operation is an error so a typo in the config does not go unnoticed
+func (t *Timeouts) Set(operation string, timeout time.Duration) error {
+	if !isOperation(operation) {
+		return fmt.Errorf("unknown repository operation %q", operation)
+	}
+	if t.Operations == nil {
+		t.Operations = make(map[string]time.Duration)
+	}
+	t.Operations[operation] = timeout
+	return nil
+}
+
+func isOperation(name string) bool {
+	for _, repository := range []reflect.Type{
+		reflect.TypeOf((*RecipeRepository)(nil)).Elem(),
+		reflect.TypeOf((*UserRepository)(nil)).Elem(),
+	} {
+		if _, ok := repository.MethodByName(name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Timeouts) context(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	timeout, ok := t.Operations[operation]
+	if !ok {
+		timeout = t.Default
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// IsTimeout tells whether a query was cut short by its deadline, postgres reports the cancel as query_canceled
+func IsTimeout(err error) bool {
+	var pqErr *pq.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &pqErr) && pqErr.Code == "57014"
+}
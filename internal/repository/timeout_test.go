@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func TestTimeouts(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	qry := "WITH due AS ( SELECT id, status FROM recipes WHERE status = 'in_review' AND publish_at <= now() AND deleted_at IS NULL ORDER BY publish_at LIMIT $1 FOR UPDATE SKIP LOCKED ), r AS ( UPDATE recipes SET status = 'published', version = version + 1, updated_at = now() FROM due WHERE recipes.id = due.id RETURNING recipes.id, due.status ) INSERT INTO recipe_status_transitions(recipe_id, from_status, to_status) SELECT id, status, 'published' FROM r"
+
+	t.Run("should cancel the query once the operation timeout has passed", func(t *testing.T) {
+		timeouts := Timeouts{Default: time.Minute}
+		if err := timeouts.Set("PublishDueRecipes", 10*time.Millisecond); err != nil {
+			t.Fatalf("error setting timeout, %v", err)
+		}
+		repo := NewRecipeRepository(db, timeouts)
+
+		mock.
+			ExpectExec(qry).
+			WithArgs(100).
+			WillDelayFor(time.Second).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		start := time.Now()
+		_, err := repo.PublishDueRecipes(context.Background(), 100)
+
+		if err == nil {
+			t.Errorf("got nil, want error")
+		}
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Errorf("got %v, want the query to be cancelled before it finished", elapsed)
+		}
+	})
+
+	t.Run("should cancel the query with the context of the caller", func(t *testing.T) {
+		repo := NewRecipeRepository(db, Timeouts{})
+
+		mock.
+			ExpectExec(qry).
+			WithArgs(100).
+			WillDelayFor(time.Second).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := repo.PublishDueRecipes(ctx, 100)
+
+		if err == nil {
+			t.Errorf("got nil, want error")
+		}
+	})
+
+	t.Run("should reject an unknown operation", func(t *testing.T) {
+		timeouts := DefaultTimeouts()
+		if err := timeouts.Set("GetRecipeByTitle", time.Second); err == nil {
+			t.Errorf("got nil, want error")
+		}
+	})
+
+	t.Run("should accept the operations of every repository", func(t *testing.T) {
+		timeouts := DefaultTimeouts()
+		for _, operation := range []string{"GetRecipeById", "InsertUser"} {
+			if err := timeouts.Set(operation, time.Second); err != nil {
+				t.Errorf("got %v setting %s, want nil", err, operation)
+			}
+		}
+	})
+
+	t.Run("should recognise a timeout", func(t *testing.T) {
+		tests := []struct {
+			err  error
+			want bool
+		}{
+			{context.DeadlineExceeded, true},
+			{fmt.Errorf("scan: %w", context.DeadlineExceeded), true},
+			{&pq.Error{Code: "57014"}, true},
+			{&pq.Error{Code: "23505"}, false},
+			{sql.ErrConnDone, false},
+		}
+
+		for _, tt := range tests {
+			if got := IsTimeout(tt.err); got != tt.want {
+				t.Errorf("IsTimeout(%v) got %v, want %v", tt.err, got, tt.want)
+			}
+		}
+	})
+}
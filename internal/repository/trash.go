@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+// a nil owner id lists the whole trash, it is meant for admins only
+func (r *recipeRepository) GetListDeletedRecipe(ctx context.Context, ownerId *int64, limit, offset int64) ([]*entity.Recipe, error) {
+	ctx, cancel := r.timeouts.context(ctx, "GetListDeletedRecipe")
+	defer cancel()
+
+	var recipes []*entity.Recipe
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, title, owner_id, deleted_at FROM recipes
+		WHERE deleted_at IS NOT NULL AND ($1::bigint IS NULL OR owner_id = $1)
+		ORDER BY deleted_at DESC
+		LIMIT $2 OFFSET $3`,
+		ownerId,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var recipe entity.Recipe
+		var deletedAt time.Time
+		if err := rows.Scan(&recipe.Id, &recipe.Title, &recipe.OwnerId, &deletedAt); err != nil {
+			return nil, err
+		}
+		recipe.DeletedAt = &deletedAt
+		recipes = append(recipes, &recipe)
+	}
+
+	return recipes, rows.Err()
+}
+
+/*
+sql.ErrNoRows is returned when the recipe is not in the trash or is not owned by the given owner,
+ErrDuplicateTitle when its title has been taken by another recipe in the meantime
+*/
+func (r *recipeRepository) RestoreDeletedRecipe(ctx context.Context, id int64, ownerId *int64) error {
+	ctx, cancel := r.timeouts.context(ctx, "RestoreDeletedRecipe")
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE recipes
+		SET deleted_at = NULL, version = version + 1, updated_at = now()
+		WHERE id = $1 AND deleted_at IS NOT NULL AND ($2::bigint IS NULL OR owner_id = $2)`,
+		id,
+		ownerId,
+	)
+	if isUniqueViolation(err, "uq_title") {
+		return ErrDuplicateTitle
+	}
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// the cutoff is computed by the database since deleted_at is stored in its clock
+func (r *recipeRepository) PurgeDeletedRecipes(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, cancel := r.timeouts.context(ctx, "PurgeDeletedRecipes")
+	defer cancel()
+
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM recipes
+		WHERE deleted_at IS NOT NULL AND deleted_at < now() - make_interval(secs => $1)`,
+		retention.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func TestGetListDeletedRecipe(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var ownerId int64 = 7
+	var limit int64 = 10
+	var offset int64 = 0
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "SELECT id, title, owner_id, deleted_at FROM recipes WHERE deleted_at IS NOT NULL AND ($1::bigint IS NULL OR owner_id = $1) ORDER BY deleted_at DESC LIMIT $2 OFFSET $3"
+
+	t.Run("should return the trash of the owner", func(t *testing.T) {
+		now := time.Now()
+
+		rows := sqlmock.
+			NewRows([]string{"id", "title", "owner_id", "deleted_at"}).
+			AddRow(1, "nasi goreng", 7, now)
+
+		mock.
+			ExpectQuery(qry).
+			WithArgs(&ownerId, limit, offset).
+			WillReturnRows(rows)
+
+		got, err := repo.GetListDeletedRecipe(context.Background(), &ownerId, limit, offset)
+
+		want := []*entity.Recipe{
+			{Id: 1, Title: "nasi goreng", OwnerId: &ownerId, DeletedAt: &now},
+		}
+
+		assertErr(t, err, nil)
+		assertRecipesEqual(t, got, want)
+	})
+
+	t.Run("should return error getting trash", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(nil, limit, offset).
+			WillReturnError(sql.ErrConnDone)
+
+		got, err := repo.GetListDeletedRecipe(context.Background(), nil, limit, offset)
+
+		assertErr(t, err, sql.ErrConnDone)
+		assertRecipesEqual(t, got, nil)
+	})
+}
+
+func TestRestoreDeletedRecipe(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	var id int64 = 1
+	var ownerId int64 = 7
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "UPDATE recipes SET deleted_at = NULL, version = version + 1, updated_at = now() WHERE id = $1 AND deleted_at IS NOT NULL AND ($2::bigint IS NULL OR owner_id = $2)"
+
+	t.Run("should return success on restore query", func(t *testing.T) {
+		mock.
+			ExpectExec(qry).
+			WithArgs(id, &ownerId).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = repo.RestoreDeletedRecipe(context.Background(), id, &ownerId)
+
+		assertErr(t, err, nil)
+	})
+
+	t.Run("should return error not found when recipe is not in the trash", func(t *testing.T) {
+		mock.
+			ExpectExec(qry).
+			WithArgs(id, &ownerId).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = repo.RestoreDeletedRecipe(context.Background(), id, &ownerId)
+
+		assertErr(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("should return error duplicate title when the title has been taken", func(t *testing.T) {
+		mock.
+			ExpectExec(qry).
+			WithArgs(id, &ownerId).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_title"})
+
+		err = repo.RestoreDeletedRecipe(context.Background(), id, &ownerId)
+
+		assertErr(t, err, ErrDuplicateTitle)
+	})
+}
+
+func TestPurgeDeletedRecipes(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewRecipeRepository(db, Timeouts{})
+
+	qry := "DELETE FROM recipes WHERE deleted_at IS NOT NULL AND deleted_at < now() - make_interval(secs => $1)"
+
+	t.Run("should return purged rows", func(t *testing.T) {
+		mock.
+			ExpectExec(qry).
+			WithArgs(float64(3600)).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		got, err := repo.PurgeDeletedRecipes(context.Background(), time.Hour)
+
+		assertErr(t, err, nil)
+		if got != 3 {
+			t.Errorf("got %d, want %d", got, 3)
+		}
+	})
+
+	t.Run("should return error on purge query", func(t *testing.T) {
+		mock.
+			ExpectExec(qry).
+			WithArgs(float64(3600)).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.PurgeDeletedRecipes(context.Background(), time.Hour)
+
+		assertErr(t, err, sql.ErrConnDone)
+	})
+}
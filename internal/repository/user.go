@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+var ErrDuplicateEmail = entity.NewError(entity.ErrConflict, "user email already exists")
+
+type UserRepository interface {
+	InsertUser(ctx context.Context, user entity.User) (int64, error)
+}
+
+type userRepository struct {
+	db       *sql.DB
+	timeouts Timeouts
+}
+
+func NewUserRepository(db *sql.DB, timeouts Timeouts) *userRepository {
+	return &userRepository{db: db, timeouts: timeouts}
+}
+
+// emails are unique regardless of case, ErrDuplicateEmail is returned when the address is taken
+func (r *userRepository) InsertUser(ctx context.Context, user entity.User) (int64, error) {
+	ctx, cancel := r.timeouts.context(ctx, "InsertUser")
+	defer cancel()
+
+	var id int64
+
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO users(email, name, role)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+		RETURNING id`,
+		user.Email,
+		user.Name,
+		user.Role,
+	).Scan(&id)
+	if isUniqueViolation(err, "uq_users_email") {
+		return 0, ErrDuplicateEmail
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
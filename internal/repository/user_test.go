@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/rhnauf/recipe-api/internal/entity"
+)
+
+func TestInsertUser(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	user := entity.User{
+		Email: "chef@example.com",
+		Name:  "chef",
+		Role:  entity.RoleEditor,
+	}
+
+	repo := NewUserRepository(db, Timeouts{})
+
+	qry := "INSERT INTO users(email, name, role) VALUES ($1, NULLIF($2, ''), NULLIF($3, '')) RETURNING id"
+
+	t.Run("should return the id of the new user", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(user.Email, user.Name, user.Role).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+		id, err := repo.InsertUser(context.Background(), user)
+
+		assertErr(t, err, nil)
+		if id != 7 {
+			t.Errorf("got %d, want %d", id, 7)
+		}
+	})
+
+	t.Run("should return error duplicate email on unique violation", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(user.Email, user.Name, user.Role).
+			WillReturnError(&pq.Error{Code: "23505", Constraint: "uq_users_email"})
+
+		_, err := repo.InsertUser(context.Background(), user)
+
+		assertErr(t, err, ErrDuplicateEmail)
+	})
+
+	t.Run("should return error on insert query", func(t *testing.T) {
+		mock.
+			ExpectQuery(qry).
+			WithArgs(user.Email, user.Name, user.Role).
+			WillReturnError(sql.ErrConnDone)
+
+		_, err := repo.InsertUser(context.Background(), user)
+
+		assertErr(t, err, sql.ErrConnDone)
+	})
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "fmt"
+
+/*
+visibleTo is the predicate of the recipes a viewer sees, published ones once their publish_at has passed,
+its own through the placeholder of viewer.Id and every recipe through the one of viewer.CanSeeDrafts(),
+alias qualifies the columns when the query joins recipes, e.g. visibleTo("r", 2, 3)
+*/
+func visibleTo(alias string, ownerArg, teamArg int) string {
+	if alias != "" {
+		alias += "."
+	}
+	return fmt.Sprintf("((%[1]sstatus = 'published' AND (%[1]spublish_at IS NULL OR %[1]spublish_at <= now())) OR %[1]sowner_id = $%[2]d OR $%[3]d)", alias, ownerArg, teamArg)
+}
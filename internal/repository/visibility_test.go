@@ -0,0 +1,24 @@
+package repository
+
+import "testing"
+
+func TestVisibleTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		alias    string
+		ownerArg int
+		teamArg  int
+		want     string
+	}{
+		{"should use the given placeholders", "", 2, 3, "((status = 'published' AND (publish_at IS NULL OR publish_at <= now())) OR owner_id = $2 OR $3)"},
+		{"should qualify every column with the alias", "r", 1, 2, "((r.status = 'published' AND (r.publish_at IS NULL OR r.publish_at <= now())) OR r.owner_id = $1 OR $2)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := visibleTo(tt.alias, tt.ownerArg, tt.teamArg); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
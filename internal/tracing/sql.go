@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type operationCtxKey struct{}
+
+/*
+ObserveOperation is a repository.Observer starting a span for every operation, the queries it runs
+become its children and are named after it, e.g. GetRecipeById sql.conn.query
+*/
+func ObserveOperation(ctx context.Context, operation string) (context.Context, func(err error)) {
+	ctx, span := tracer().Start(ctx, "repository "+operation, trace.WithAttributes(semconv.CodeFunction(operation)))
+	ctx = context.WithValue(ctx, operationCtxKey{}, operation)
+
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// Open opens a postgres pool whose queries are traced, the statement is kept as db.statement
+func Open(dataSourceName string) (*sql.DB, error) {
+	return otelsql.Open("postgres", dataSourceName,
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanNameFormatter(spanName),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}),
+	)
+}
+
+func spanName(ctx context.Context, method otelsql.Method, query string) string {
+	if operation, ok := ctx.Value(operationCtxKey{}).(string); ok {
+		return operation + " " + string(method)
+	}
+	return string(method)
+}
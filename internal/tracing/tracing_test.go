@@ -0,0 +1,93 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/XSAM/otelsql"
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/recipe/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/recipe/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want %d", len(spans), 1)
+	}
+	span := spans[0]
+
+	t.Run("should name the span after the route pattern", func(t *testing.T) {
+		if span.Name() != "GET /recipe/{id}" {
+			t.Errorf("got %s, want %s", span.Name(), "GET /recipe/{id}")
+		}
+	})
+
+	t.Run("should continue the trace of the traceparent header", func(t *testing.T) {
+		if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("got trace %s, want the one of the header", got)
+		}
+		if got := span.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+			t.Errorf("got parent %s, want the one of the header", got)
+		}
+	})
+
+	t.Run("should mark a server error", func(t *testing.T) {
+		if span.Status().Code != codes.Error {
+			t.Errorf("got status %v, want %v", span.Status().Code, codes.Error)
+		}
+	})
+}
+
+func TestObserveOperation(t *testing.T) {
+	recorder := recordSpans(t)
+
+	ctx, end := ObserveOperation(context.Background(), "GetRecipeById")
+
+	t.Run("should name the queries after the operation", func(t *testing.T) {
+		if got := spanName(ctx, otelsql.MethodConnQuery, ""); got != "GetRecipeById sql.conn.query" {
+			t.Errorf("got %s, want %s", got, "GetRecipeById sql.conn.query")
+		}
+		if got := spanName(context.Background(), otelsql.MethodConnQuery, ""); got != "sql.conn.query" {
+			t.Errorf("got %s, want %s", got, "sql.conn.query")
+		}
+	})
+
+	end(sql.ErrNoRows)
+
+	t.Run("should record the error of the operation", func(t *testing.T) {
+		spans := recorder.Ended()
+		if len(spans) != 1 {
+			t.Fatalf("got %d spans, want %d", len(spans), 1)
+		}
+		if spans[0].Name() != "repository GetRecipeById" || spans[0].Status().Code != codes.Error {
+			t.Errorf("got %s %v, want repository GetRecipeById with an error", spans[0].Name(), spans[0].Status().Code)
+		}
+	})
+}